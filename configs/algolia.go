@@ -29,14 +29,21 @@ func (cfg *AlgoliaConfig) Bootstrap(provider resources.Provider) error {
 	return nil
 }
 
-// Valid returns an error if the configuration is NOT valid.
-func (cfg AlgoliaConfig) Valid() error {
+// Validate returns an error if the configuration is NOT valid.
+func (cfg AlgoliaConfig) Validate() error {
 	if !cfg.complete {
 		return ErrConfigNotBootstrapped
 	}
 	return cfg.resource.Validate()
 }
 
+// Valid returns an error if the configuration is NOT valid.
+//
+// Deprecated: use AlgoliaConfig.Validate.
+func (cfg AlgoliaConfig) Valid() error {
+	return cfg.Validate()
+}
+
 // Resource for this configuration. Requires previous call to Bootstrap.
 func (cfg AlgoliaConfig) Resource() resources.Algolia {
 	return cfg.resource
@@ -1,6 +1,11 @@
 package configs
 
-import "github.com/vredens/infrastructure/resources"
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/vredens/infrastructure/resources"
+)
 
 // Dynamo defines the configuration required for a dynamo client.
 type Dynamo struct {
@@ -33,3 +38,11 @@ func (cfg Dynamo) Validate() error {
 func (cfg Dynamo) Resource() resources.Dynamo {
 	return cfg.resource
 }
+
+// AWSConfig for creating the client with the session of the resource, see AWSConfig.
+func (cfg Dynamo) AWSConfig(ctx context.Context) (aws.Config, error) {
+	if !cfg.complete {
+		return aws.Config{}, ErrConfigNotBootstrapped
+	}
+	return AWSConfig(ctx, cfg.resource.Session)
+}
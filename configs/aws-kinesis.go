@@ -1,8 +1,10 @@
 package configs
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/vredens/infrastructure/resources"
 )
 
@@ -73,3 +75,19 @@ func (cfg KinesisProducer) Validate() error {
 func (cfg KinesisProducer) Resource() resources.KinesisProducer {
 	return cfg.resource
 }
+
+// AWSConfig for creating the client with the session of the resource, see AWSConfig.
+func (cfg KinesisConsumer) AWSConfig(ctx context.Context) (aws.Config, error) {
+	if !cfg.complete {
+		return aws.Config{}, ErrConfigNotBootstrapped
+	}
+	return AWSConfig(ctx, cfg.resource.Session)
+}
+
+// AWSConfig for creating the client with the session of the resource, see AWSConfig.
+func (cfg KinesisProducer) AWSConfig(ctx context.Context) (aws.Config, error) {
+	if !cfg.complete {
+		return aws.Config{}, ErrConfigNotBootstrapped
+	}
+	return AWSConfig(ctx, cfg.resource.Session)
+}
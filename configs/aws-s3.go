@@ -1,6 +1,12 @@
 package configs
 
-import "github.com/vredens/infrastructure/resources"
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/vredens/infrastructure/resources"
+)
 
 // S3Manager defines the configuration required for an s3 client.
 type S3Manager struct {
@@ -33,3 +39,17 @@ func (cfg S3Manager) Validate() error {
 func (cfg S3Manager) Resource() resources.S3Manager {
 	return cfg.resource
 }
+
+// AWSConfig for creating the client with the session of the resource, see AWSConfig.
+func (cfg S3Manager) AWSConfig(ctx context.Context) (aws.Config, error) {
+	if !cfg.complete {
+		return aws.Config{}, ErrConfigNotBootstrapped
+	}
+	return AWSConfig(ctx, cfg.resource.Session)
+}
+
+// S3Options with the S3 specific settings of the session of the resource, e.g.
+// `s3.NewFromConfig(awsConfig, cfg.S3Options())`.
+func (cfg S3Manager) S3Options() func(*s3.Options) {
+	return AWSS3Options(cfg.resource.Session)
+}
@@ -1,8 +1,10 @@
 package configs
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/vredens/infrastructure/resources"
 )
 
@@ -75,3 +77,19 @@ func (cfg SQSProducer) Validate() error {
 func (cfg SQSProducer) Resource() resources.SQSProducerResource {
 	return cfg.resource
 }
+
+// AWSConfig for creating the client with the session of the resource, see AWSConfig.
+func (cfg SQSConsumer) AWSConfig(ctx context.Context) (aws.Config, error) {
+	if !cfg.complete {
+		return aws.Config{}, ErrConfigNotBootstrapped
+	}
+	return AWSConfig(ctx, cfg.resource.Session)
+}
+
+// AWSConfig for creating the client with the session of the resource, see AWSConfig.
+func (cfg SQSProducer) AWSConfig(ctx context.Context) (aws.Config, error) {
+	if !cfg.complete {
+		return aws.Config{}, ErrConfigNotBootstrapped
+	}
+	return AWSConfig(ctx, cfg.resource.Session)
+}
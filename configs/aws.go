@@ -0,0 +1,139 @@
+package configs
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
+	"github.com/vredens/infrastructure/resources"
+)
+
+// AWSSession configuration for AWS clients not covered by other configurations.
+type AWSSession struct {
+	ResourceName string `json:"arn"`
+	resource     resources.AWSSession
+	complete     bool
+}
+
+// Bootstrap configuration.
+func (cfg *AWSSession) Bootstrap(provider resources.Provider) error {
+	cfg.resource = provider.Locator().LocateAWSSession(cfg.ResourceName)
+	if err := cfg.resource.Validate(); err != nil {
+		return fmt.Errorf("invalid aws session resource %s; %w", cfg.ResourceName, err)
+	}
+
+	cfg.complete = true
+
+	return nil
+}
+
+// Validate returns an error if the configuration is NOT valid.
+func (cfg AWSSession) Validate() error {
+	if !cfg.complete {
+		return ErrConfigNotBootstrapped
+	}
+	return cfg.resource.Validate()
+}
+
+// Resource with the infrastructure configuration.
+func (cfg AWSSession) Resource() resources.AWSSession {
+	return cfg.resource
+}
+
+// AWSConfig for creating clients of the AWS SDK, see AWSConfig.
+func (cfg AWSSession) AWSConfig(ctx context.Context) (aws.Config, error) {
+	if !cfg.complete {
+		return aws.Config{}, ErrConfigNotBootstrapped
+	}
+	return AWSConfig(ctx, cfg.resource)
+}
+
+// AWSConfig for creating clients of the AWS SDK with the settings of the session.
+//
+// The configuration is loaded like the SDK does by default, from environment variables, the shared files of the AWS
+// CLI and the role of the container or instance, using the Profile and Region of the session if set. The static
+// credentials of the session, when set, replace the default ones. When the session has a Role it is assumed, through
+// web identity if the session has a WebIdentityTokenFile, with the credentials loaded. The Endpoint of the session
+// is used by every client except the STS one used for assuming the Role.
+func AWSConfig(ctx context.Context, session resources.AWSSession) (aws.Config, error) {
+	var options []func(*config.LoadOptions) error
+	if session.Region != "" {
+		options = append(options, config.WithRegion(session.Region))
+	}
+	if session.Profile != "" {
+		options = append(options, config.WithSharedConfigProfile(session.Profile))
+	}
+	if !session.Credentials.IsZero() {
+		options = append(options, config.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(
+			session.Credentials.AccessKeyID, session.Credentials.SecretAccessKey, session.Credentials.Token,
+		)))
+	}
+	awsConfig, err := config.LoadDefaultConfig(ctx, options...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("failed to load aws configuration; %w", err)
+	}
+
+	if session.Role != "" {
+		client := sts.NewFromConfig(awsConfig)
+		if session.WebIdentityTokenFile != "" {
+			awsConfig.Credentials = aws.NewCredentialsCache(stscreds.NewWebIdentityRoleProvider(
+				client, session.Role, stscreds.IdentityTokenFile(session.WebIdentityTokenFile),
+				func(o *stscreds.WebIdentityRoleOptions) {
+					o.RoleSessionName = session.SessionName
+				},
+			))
+		} else {
+			awsConfig.Credentials = aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(
+				client, session.Role,
+				func(o *stscreds.AssumeRoleOptions) {
+					if session.ExternalID != "" {
+						o.ExternalID = aws.String(session.ExternalID)
+					}
+					if session.SessionName != "" {
+						o.RoleSessionName = session.SessionName
+					}
+				},
+			))
+		}
+	}
+
+	if session.Endpoint != "" {
+		endpoint := session.Endpoint
+		if !strings.Contains(endpoint, "://") {
+			endpoint = "https://" + endpoint
+			if session.DisableSSL {
+				endpoint = "http://" + session.Endpoint
+			}
+		}
+		awsConfig.BaseEndpoint = aws.String(endpoint)
+	}
+	if session.DisableEndpointHostPrefix {
+		awsConfig.APIOptions = append(awsConfig.APIOptions, disableEndpointHostPrefix)
+	}
+	return awsConfig, nil
+}
+
+// AWSS3Options with the S3 specific settings of the session, for use with s3.NewFromConfig.
+func AWSS3Options(session resources.AWSSession) func(*s3.Options) {
+	return func(o *s3.Options) {
+		o.UsePathStyle = session.S3ForcePathStyle
+	}
+}
+
+// disableEndpointHostPrefix stops clients from prefixing the host of the endpoint for operations which would, such as
+// the account ID of S3 Control operations, which endpoints like localstack don't support.
+func disableEndpointHostPrefix(stack *middleware.Stack) error {
+	return stack.Initialize.Add(middleware.InitializeMiddlewareFunc("DisableEndpointHostPrefix",
+		func(ctx context.Context, in middleware.InitializeInput, next middleware.InitializeHandler) (middleware.InitializeOutput, middleware.Metadata, error) {
+			return next.HandleInitialize(smithyhttp.DisableEndpointHostPrefix(ctx, true), in)
+		},
+	), middleware.Before)
+}
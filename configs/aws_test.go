@@ -0,0 +1,259 @@
+package configs_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/vredens/infrastructure"
+	"github.com/vredens/infrastructure/configs"
+)
+
+// awsStub answers STS AssumeRole and AssumeRoleWithWebIdentity calls, issuing credentials named after the action, and
+// records every S3 request.
+type awsStub struct {
+	mu       sync.Mutex
+	sts      []map[string]string
+	s3       []*http.Request
+	endpoint string
+}
+
+func (stub *awsStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+	if r.Method == http.MethodPost && r.URL.Path == "/" {
+		r.ParseForm()
+		form := make(map[string]string)
+		for key := range r.PostForm {
+			form[key] = r.PostForm.Get(key)
+		}
+		stub.sts = append(stub.sts, form)
+		action := form["Action"]
+		w.Header().Set("Content-Type", "text/xml")
+		fmt.Fprintf(w, `<%[1]sResponse xmlns="https://sts.amazonaws.com/doc/2011-06-15/"><%[1]sResult><Credentials>
+<AccessKeyId>AKID%[2]s</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>token</SessionToken>
+<Expiration>%[3]s</Expiration></Credentials></%[1]sResult></%[1]sResponse>`,
+			action, strings.ToUpper(action), time.Now().Add(time.Hour).UTC().Format(time.RFC3339))
+		return
+	}
+	stub.s3 = append(stub.s3, r)
+	w.Header().Set("Content-Type", "application/xml")
+	fmt.Fprint(w, `<ListBucketResult xmlns="http://s3.amazonaws.com/doc/2006-03-01/"><Name>assets</Name></ListBucketResult>`)
+}
+
+func TestAWSConfig(t *testing.T) {
+	stub := &awsStub{}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	dir := t.TempDir()
+	tokenFile := filepath.Join(dir, "token")
+	credentialsFile := filepath.Join(dir, "credentials")
+	writeTestFile(t, tokenFile, "web-identity-token")
+	writeTestFile(t, credentialsFile, "[tests]\naws_access_key_id = AKIDPROFILE\naws_secret_access_key = profile-secret\n")
+	for _, variable := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_PROFILE", "AWS_ROLE_ARN", "AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_ENDPOINT_URL"} {
+		t.Setenv(variable, "")
+	}
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(dir, "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentialsFile)
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	t.Setenv("AWS_ENDPOINT_URL_STS", server.URL)
+	t.Setenv("AWS_TEST_ENDPOINT", server.URL)
+	t.Setenv("AWS_TEST_TOKEN_FILE", tokenFile)
+
+	provider, err := infrastructure.NewProvider(infrastructure.ProviderSettings{
+		EnvName:       "aws-tests",
+		SystemName:    "tests",
+		ComponentName: "test",
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx := context.Background()
+
+	session := func(t *testing.T, arn string) aws.Config {
+		cfg := configs.AWSSession{ResourceName: arn}
+		if !assert.NoError(t, cfg.Bootstrap(provider)) {
+			t.FailNow()
+		}
+		awsConfig, err := cfg.AWSConfig(ctx)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		return awsConfig
+	}
+
+	_, err = configs.AWSSession{ResourceName: "arn://cloud/aws/static"}.AWSConfig(ctx)
+	assert.ErrorIs(t, err, configs.ErrConfigNotBootstrapped)
+	assert.Error(t, (&configs.AWSSession{ResourceName: "arn://cloud/aws/invalid"}).Bootstrap(provider))
+
+	t.Run("static", func(t *testing.T) {
+		awsConfig := session(t, "arn://cloud/aws/static")
+		assert.Equal(t, "eu-west-1", awsConfig.Region)
+		assert.Equal(t, server.URL, aws.ToString(awsConfig.BaseEndpoint))
+		creds, err := awsConfig.Credentials.Retrieve(ctx)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "AKIDSTATIC", creds.AccessKeyID)
+		}
+	})
+
+	t.Run("role", func(t *testing.T) {
+		creds, err := session(t, "arn://cloud/aws/role").Credentials.Retrieve(ctx)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, "AKIDASSUMEROLE", creds.AccessKeyID)
+		request := stub.sts[len(stub.sts)-1]
+		assert.Equal(t, "arn:aws:iam::123456789012:role/tests", request["RoleArn"])
+		assert.Equal(t, "ext-123", request["ExternalId"])
+		assert.Equal(t, "tests-session", request["RoleSessionName"])
+	})
+
+	t.Run("web-identity", func(t *testing.T) {
+		creds, err := session(t, "arn://cloud/aws/web-identity").Credentials.Retrieve(ctx)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, "AKIDASSUMEROLEWITHWEBIDENTITY", creds.AccessKeyID)
+		request := stub.sts[len(stub.sts)-1]
+		assert.Equal(t, "arn:aws:iam::123456789012:role/web", request["RoleArn"])
+		assert.Equal(t, "web-identity-token", request["WebIdentityToken"])
+	})
+
+	t.Run("profile", func(t *testing.T) {
+		creds, err := session(t, "arn://cloud/aws/profile").Credentials.Retrieve(ctx)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "AKIDPROFILE", creds.AccessKeyID)
+		}
+	})
+
+	t.Run("s3", func(t *testing.T) {
+		cfg := configs.S3Manager{ResourceName: "arn://storage/s3/assets"}
+		if !assert.NoError(t, cfg.Bootstrap(provider)) {
+			t.FailNow()
+		}
+		awsConfig, err := cfg.AWSConfig(ctx)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		client := s3.NewFromConfig(awsConfig, cfg.S3Options())
+		_, err = client.ListObjectsV2(ctx, &s3.ListObjectsV2Input{Bucket: aws.String("assets")})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		request := stub.s3[len(stub.s3)-1]
+		assert.Equal(t, "/assets", request.URL.Path)
+		assert.Contains(t, request.Header.Get("Authorization"), "Credential=AKIDASSUMEROLE/")
+	})
+
+	t.Run("dynamo", func(t *testing.T) {
+		cfg := configs.Dynamo{ResourceName: "arn://storage/dynamo/users"}
+		if !assert.NoError(t, cfg.Bootstrap(provider)) {
+			t.FailNow()
+		}
+		awsConfig, err := cfg.AWSConfig(ctx)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		creds, err := awsConfig.Credentials.Retrieve(ctx)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "AKIDSTATIC", creds.AccessKeyID)
+		}
+	})
+}
+
+func TestAWSMessagingSessions(t *testing.T) {
+	for _, variable := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_PROFILE", "AWS_ENDPOINT_URL"} {
+		t.Setenv(variable, "")
+	}
+	t.Setenv("AWS_CONFIG_FILE", filepath.Join(t.TempDir(), "config"))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "credentials"))
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	t.Setenv("AWS_TEST_ENDPOINT", "http://localhost:4566")
+
+	provider, err := infrastructure.NewProvider(infrastructure.ProviderSettings{
+		EnvName:       "aws-tests",
+		SystemName:    "tests",
+		ComponentName: "test",
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ctx := context.Background()
+
+	t.Run("sqs/legacy", func(t *testing.T) {
+		cfg := configs.SQSConsumer{ResourceName: "arn://messaging/sqs/consumers/legacy"}
+		if !assert.NoError(t, cfg.Bootstrap(provider)) {
+			t.FailNow()
+		}
+		assert.Equal(t, "http://localhost:4566", cfg.Resource().Session.Endpoint)
+		assert.Equal(t, "us-east-1", cfg.Resource().Session.Region)
+		awsConfig, err := cfg.AWSConfig(ctx)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "us-east-1", awsConfig.Region)
+			assert.Equal(t, "http://localhost:4566", aws.ToString(awsConfig.BaseEndpoint))
+		}
+	})
+
+	t.Run("sqs/referenced", func(t *testing.T) {
+		cfg := configs.SQSConsumer{ResourceName: "arn://messaging/sqs/consumers/referenced"}
+		if !assert.NoError(t, cfg.Bootstrap(provider)) {
+			t.FailNow()
+		}
+		assert.Equal(t, "arn:aws:iam::123456789012:role/tests", cfg.Resource().Session.Role)
+		assert.Equal(t, "eu-west-1", cfg.Resource().AWS.Region)
+	})
+
+	t.Run("sqs/session", func(t *testing.T) {
+		cfg := configs.SQSProducer{ResourceName: "arn://messaging/sqs/producers/session"}
+		if !assert.NoError(t, cfg.Bootstrap(provider)) {
+			t.FailNow()
+		}
+		awsConfig, err := cfg.AWSConfig(ctx)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, "eu-central-1", awsConfig.Region)
+		assert.Equal(t, "http://localhost:4566", aws.ToString(awsConfig.BaseEndpoint))
+		creds, err := awsConfig.Credentials.Retrieve(ctx)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "AKIDSQS", creds.AccessKeyID)
+		}
+	})
+
+	t.Run("kinesis/referenced", func(t *testing.T) {
+		cfg := configs.KinesisConsumer{ResourceName: "arn://messaging/kinesis/consumers/referenced"}
+		if !assert.NoError(t, cfg.Bootstrap(provider)) {
+			t.FailNow()
+		}
+		awsConfig, err := cfg.AWSConfig(ctx)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		creds, err := awsConfig.Credentials.Retrieve(ctx)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "AKIDSTATIC", creds.AccessKeyID)
+		}
+	})
+
+	t.Run("kinesis/invalid-session", func(t *testing.T) {
+		cfg := configs.KinesisProducer{ResourceName: "arn://messaging/kinesis/producers/invalid-session"}
+		assert.Error(t, cfg.Bootstrap(provider))
+	})
+}
+
+func writeTestFile(t *testing.T, path string, content string) {
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -1,6 +1,19 @@
 package configs
 
-import "github.com/vredens/infrastructure/resources"
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/vredens/infrastructure/lib/certs"
+	"github.com/vredens/infrastructure/resources"
+)
 
 // Webservice configuration for connecting to a webservice.
 type Webservice struct {
@@ -10,8 +23,9 @@ type Webservice struct {
 		// Timeout for new connections, in milliseconds.
 		Timeout int `json:"timeout"`
 	} `json:"params"`
-	resource resources.Webservice
-	complete bool
+	resource  resources.Webservice
+	tlsConfig *tls.Config
+	complete  bool
 }
 
 // HTTPConnection for finetuning the connection.
@@ -30,6 +44,13 @@ func (cfg *Webservice) Bootstrap(provider resources.Provider) error {
 	if err := cfg.resource.Validate(); err != nil {
 		return err
 	}
+	if _, err := url.Parse(cfg.resource.BaseURL); err != nil {
+		return fmt.Errorf("invalid url of webservice %s; %w", cfg.ResourceName, err)
+	}
+
+	if provided, ok := provider.(interface{ Certs() certs.Certs }); ok {
+		cfg.tlsConfig = provided.Certs().NewTLSClientConfig()
+	}
 
 	cfg.complete = true
 
@@ -48,3 +69,150 @@ func (cfg Webservice) Validate() error {
 func (cfg Webservice) Resource() resources.Webservice {
 	return cfg.resource
 }
+
+// NewClient for the webservice. The client trusts the certificates of the Provider, adds the headers of the resource
+// and its authorisation to every request and resolves requests with relative URLs, e.g. `users/1`, against the URL of
+// the resource.
+//
+// The authorisation type of the resource is checked here, rather than when bootstrapping, so that resources with types
+// other clients support can still be bootstrapped.
+//
+// With OAuth2 authorisation, tokens are fetched when needed and shared by every request of the client. Requests
+// rejected with a 401 are retried once with a new token, unless their body can not be read again.
+func (cfg Webservice) NewClient() (*http.Client, error) {
+	if !cfg.complete {
+		return nil, ErrConfigNotBootstrapped
+	}
+	baseURL, err := url.Parse(cfg.resource.BaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url of webservice %s; %w", cfg.ResourceName, err)
+	}
+	authorisation := cfg.resource.Authorisation
+	authorisation.Type = strings.ToLower(authorisation.Type)
+	switch authorisation.Type {
+	case "", resources.AuthBearer, resources.AuthBasic, resources.AuthAPIKey, resources.AuthOAuth2:
+	default:
+		return nil, fmt.Errorf("unsupported authorisation type %s of webservice %s", cfg.resource.Authorisation.Type, cfg.ResourceName)
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   time.Duration(cfg.Params.Timeout) * time.Millisecond,
+		KeepAlive: time.Duration(cfg.Params.Connection.KeepAlive) * time.Second,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	if cfg.Params.Connection.MaxIdle > 0 {
+		transport.MaxIdleConns = cfg.Params.Connection.MaxIdle
+		transport.MaxIdleConnsPerHost = cfg.Params.Connection.MaxIdle
+	}
+	transport.MaxConnsPerHost = cfg.Params.Connection.MaxPerHost
+	if cfg.tlsConfig != nil {
+		transport.TLSClientConfig = cfg.tlsConfig.Clone()
+	}
+
+	webservice := &webserviceTransport{
+		base:          transport,
+		baseURL:       baseURL,
+		headers:       cfg.resource.Headers,
+		authorisation: authorisation,
+	}
+	if authorisation.Type == resources.AuthOAuth2 {
+		webservice.tokens = &tokenSource{
+			credentials: authorisation.OAuth2,
+			client:      &http.Client{Transport: transport},
+		}
+	}
+	return &http.Client{Transport: webservice}, nil
+}
+
+// webserviceTransport completes requests with the URL, headers and authorisation of a webservice.
+type webserviceTransport struct {
+	base          http.RoundTripper
+	baseURL       *url.URL
+	headers       map[string]string
+	authorisation resources.WebserviceAuthorisation
+	tokens        *tokenSource
+}
+
+func (t *webserviceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// round trippers must not modify the request they are given.
+	req = req.Clone(req.Context())
+	if req.URL.Host == "" {
+		req.URL = t.resolve(req.URL)
+		req.Host = ""
+	}
+	for name, value := range t.headers {
+		if req.Header.Get(name) == "" {
+			req.Header.Set(name, value)
+		}
+	}
+	if t.tokens == nil {
+		t.authorise(req)
+		return t.base.RoundTrip(req)
+	}
+
+	token, err := t.tokens.Token(req.Context())
+	if err != nil {
+		return nil, err
+	}
+	retry := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	req.Header.Set("Authorization", "Bearer "+token)
+	res, err := t.base.RoundTrip(req)
+	if err != nil || res.StatusCode != http.StatusUnauthorized || !retry {
+		return res, err
+	}
+
+	// the token may have been revoked before expiring, so it is replaced and the request sent again.
+	t.tokens.Invalidate(token)
+	if token, err = t.tokens.Token(req.Context()); err != nil {
+		return res, nil
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	req = req.Clone(req.Context())
+	if req.GetBody != nil {
+		if req.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return t.base.RoundTrip(req)
+}
+
+// resolve the relative URL against the base URL, keeping the path of the base URL.
+func (t *webserviceTransport) resolve(relative *url.URL) *url.URL {
+	resolved := *t.baseURL
+	resolved.Path = strings.TrimRight(t.baseURL.Path, "/") + "/" + strings.TrimLeft(relative.Path, "/")
+	resolved.RawPath = ""
+	query := t.baseURL.Query()
+	for key, values := range relative.Query() {
+		query[key] = values
+	}
+	resolved.RawQuery = query.Encode()
+	resolved.Fragment = relative.Fragment
+	return &resolved
+}
+
+func (t *webserviceTransport) authorise(req *http.Request) {
+	auth := t.authorisation
+	switch auth.Type {
+	case resources.AuthBearer:
+		req.Header.Set("Authorization", "Bearer "+auth.Key)
+	case resources.AuthBasic:
+		req.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(auth.Key)))
+	case resources.AuthAPIKey:
+		if auth.Query != "" {
+			query := req.URL.Query()
+			query.Set(auth.Query, auth.Key)
+			req.URL.RawQuery = query.Encode()
+			if auth.Header == "" {
+				return
+			}
+		}
+		header := auth.Header
+		if header == "" {
+			header = "X-API-Key"
+		}
+		req.Header.Set(header, auth.Key)
+	}
+}
@@ -0,0 +1,257 @@
+package configs_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vredens/infrastructure"
+	"github.com/vredens/infrastructure/configs"
+)
+
+func TestWebserviceClient(t *testing.T) {
+	var received *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	os.Setenv("WEBSERVICE_TEST_URL", server.URL)
+
+	provider, err := infrastructure.NewProvider(infrastructure.ProviderSettings{
+		EnvName:       "http-tests",
+		SystemName:    "tests",
+		ComponentName: "test",
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	var cfg struct {
+		Bearer       configs.Webservice `json:"bearer"`
+		Basic        configs.Webservice `json:"basic"`
+		APIKeyHeader configs.Webservice `json:"api-key-header"`
+		APIKeyQuery  configs.Webservice `json:"api-key-query"`
+		Invalid      configs.Webservice `json:"invalid"`
+	}
+	if !assert.NoError(t, provider.LoadConfig("http", &cfg)) {
+		t.FailNow()
+	}
+
+	_, err = cfg.Bearer.NewClient()
+	assert.ErrorIs(t, err, configs.ErrConfigNotBootstrapped)
+	if assert.NoError(t, cfg.Invalid.Bootstrap(provider)) {
+		_, err = cfg.Invalid.NewClient()
+		assert.ErrorContains(t, err, "unsupported authorisation type digest")
+	}
+
+	get := func(t *testing.T, cfg configs.Webservice, target string) {
+		if !assert.NoError(t, cfg.Bootstrap(provider)) {
+			t.FailNow()
+		}
+		client, err := cfg.NewClient()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		received = nil
+		res, err := client.Get(target)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		res.Body.Close()
+		if !assert.NotNil(t, received) {
+			t.FailNow()
+		}
+	}
+
+	t.Run("bearer", func(t *testing.T) {
+		get(t, cfg.Bearer, "/users/1?expand=roles")
+		assert.Equal(t, "/v1/users/1", received.URL.Path)
+		assert.Equal(t, "roles", received.URL.Query().Get("expand"))
+		assert.Equal(t, "Bearer token", received.Header.Get("Authorization"))
+		assert.Equal(t, "tests", received.Header.Get("X-Client"))
+	})
+
+	t.Run("absolute", func(t *testing.T) {
+		get(t, cfg.Bearer, server.URL+"/health")
+		assert.Equal(t, "/health", received.URL.Path)
+		assert.Equal(t, "Bearer token", received.Header.Get("Authorization"))
+	})
+
+	t.Run("basic", func(t *testing.T) {
+		get(t, cfg.Basic, "users")
+		assert.Equal(t, "/users", received.URL.Path)
+		user, pass, ok := received.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "user", user)
+		assert.Equal(t, "pass", pass)
+	})
+
+	t.Run("api-key-header", func(t *testing.T) {
+		get(t, cfg.APIKeyHeader, "users")
+		assert.Equal(t, "secret", received.Header.Get("X-Token"))
+	})
+
+	t.Run("api-key-query", func(t *testing.T) {
+		get(t, cfg.APIKeyQuery, "users")
+		assert.Equal(t, "secret", received.URL.Query().Get("api_key"))
+		assert.Equal(t, "2", received.URL.Query().Get("version"))
+		assert.Empty(t, received.Header.Get("X-API-Key"))
+	})
+}
+
+func TestWebserviceOAuth2(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		issued    int
+		expiresIn = 3600
+		revoked   = map[string]bool{}
+		bodies    []string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.URL.Path {
+		case "/token":
+			id, secret, _ := r.BasicAuth()
+			if id != "client" || secret != "s3cr3t" || r.PostFormValue("grant_type") != "client_credentials" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			assert.Equal(t, "read write", r.PostFormValue("scope"))
+			assert.Equal(t, "partners", r.PostFormValue("audience"))
+			issued++
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"access_token": "token-%d", "token_type": "Bearer", "expires_in": %d}`, issued, expiresIn)
+		case "/api/items":
+			token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if token == "" || revoked[token] {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			body, _ := io.ReadAll(r.Body)
+			bodies = append(bodies, string(body))
+			fmt.Fprint(w, token)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	os.Setenv("WEBSERVICE_TEST_URL", server.URL)
+	os.Setenv("WEBSERVICE_TEST_SECRET", "s3cr3t")
+
+	provider, err := infrastructure.NewProvider(infrastructure.ProviderSettings{
+		EnvName:       "http-tests",
+		SystemName:    "tests",
+		ComponentName: "test",
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	var cfg struct {
+		OAuth2        configs.Webservice `json:"oauth2"`
+		MissingSecret configs.Webservice `json:"oauth2-missing-secret"`
+	}
+	if !assert.NoError(t, provider.LoadConfig("http", &cfg)) {
+		t.FailNow()
+	}
+	assert.Error(t, cfg.MissingSecret.Bootstrap(provider))
+	if !assert.NoError(t, cfg.OAuth2.Bootstrap(provider)) {
+		t.FailNow()
+	}
+
+	post := func(t *testing.T, client *http.Client, body string) string {
+		res, err := client.Post("items", "text/plain", strings.NewReader(body))
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		defer res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		token, _ := io.ReadAll(res.Body)
+		return string(token)
+	}
+
+	t.Run("cached", func(t *testing.T) {
+		client, err := cfg.OAuth2.NewClient()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				post(t, client, "item")
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 1, issued)
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		client, err := cfg.OAuth2.NewClient()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		token := post(t, client, "first")
+		mu.Lock()
+		revoked[token] = true
+		bodies = nil
+		mu.Unlock()
+		assert.NotEqual(t, token, post(t, client, "second"))
+		assert.Equal(t, []string{"second"}, bodies)
+	})
+
+	t.Run("short-lived", func(t *testing.T) {
+		mu.Lock()
+		expiresIn = 10
+		mu.Unlock()
+		client, err := cfg.OAuth2.NewClient()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, post(t, client, "first"), post(t, client, "second"))
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		mu.Lock()
+		expiresIn = 1
+		mu.Unlock()
+		client, err := cfg.OAuth2.NewClient()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		token := post(t, client, "first")
+		time.Sleep(600 * time.Millisecond)
+		assert.NotEqual(t, token, post(t, client, "second"))
+	})
+
+	t.Run("token-error", func(t *testing.T) {
+		os.Setenv("WEBSERVICE_TEST_SECRET", "wrong")
+		defer os.Setenv("WEBSERVICE_TEST_SECRET", "s3cr3t")
+		wrong, err := infrastructure.NewProvider(infrastructure.ProviderSettings{
+			EnvName:       "http-tests",
+			SystemName:    "tests",
+			ComponentName: "test",
+		})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		ws := configs.Webservice{ResourceName: "arn://webservices/oauth2"}
+		if !assert.NoError(t, ws.Bootstrap(wrong)) {
+			t.FailNow()
+		}
+		client, err := ws.NewClient()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		_, err = client.Get("items")
+		assert.Error(t, err)
+	})
+}
@@ -1,11 +1,45 @@
 package configs
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/vredens/infrastructure/resources"
 )
 
+// KafkaClientConfig holds the settings of a Kafka client, for building the options of any Go Kafka client such as
+// sarama, kafka-go or franz-go. For confluent-kafka-go, and other librdkafka based clients, see LibrdkafkaConfig.
+type KafkaClientConfig struct {
+	Brokers []string
+	// Topic with any prefix, suffix or translation of the cluster applied.
+	Topic string
+	// Group of the consumer, with any prefix or suffix of the cluster applied. Empty for producers.
+	Group string
+	// Offset from which the consumer starts when the group has no committed offsets. Empty for producers.
+	Offset KafkaOffset
+	// TLS configuration for connecting to the brokers. Nil unless the security protocol is SSL or SASL_SSL.
+	TLS *tls.Config
+	// SASL authentication. Nil unless the security protocol is SASL_PLAINTEXT or SASL_SSL.
+	SASL *KafkaSASL
+}
+
+// KafkaSASL authentication settings.
+type KafkaSASL struct {
+	// Mechanism is one of PLAIN, SCRAM-SHA-256, SCRAM-SHA-512 or OAUTHBEARER.
+	Mechanism string
+	Username  string
+	Password  string
+	// Token for the OAUTHBEARER mechanism, obtained through the OAuth2 client credentials of the cluster and cached
+	// until shortly before it expires. Nil for other mechanisms.
+	Token func(ctx context.Context) (string, error)
+}
+
 // KafkaCluster configuration.
 type KafkaCluster struct {
 	ResourceName string `json:"arn"`
@@ -52,6 +86,91 @@ func (cfg KafkaCluster) GroupNameFor(group string) string {
 	return cfg.resource.GroupPrefix + group + cfg.resource.GroupSuffix
 }
 
+// KafkaOffsetKind is the kind of a KafkaOffset.
+type KafkaOffsetKind int
+
+const (
+	// OffsetDefault leaves the initial offset to the default of the driver.
+	OffsetDefault KafkaOffsetKind = iota
+	// OffsetEarliest is the oldest message of each partition.
+	OffsetEarliest
+	// OffsetLatest is the end of each partition, for consuming only new messages.
+	OffsetLatest
+	// OffsetRelative is a number of messages after the oldest message, when positive, or before the end, when negative.
+	OffsetRelative
+	// OffsetTimestamp is the first message of each partition produced at or after a point in time.
+	OffsetTimestamp
+	// OffsetAbsolute is an offset of every partition or, when Partitions is set, the offset of each partition.
+	OffsetAbsolute
+)
+
+// KafkaOffset from which a consumer starts, as parsed by ParseKafkaOffset.
+type KafkaOffset struct {
+	Kind KafkaOffsetKind
+	// Value is the number of messages of an OffsetRelative or the offset of an OffsetAbsolute.
+	Value int64
+	// Timestamp of an OffsetTimestamp.
+	Timestamp time.Time
+	// Partitions with their offsets, for an OffsetAbsolute set per partition.
+	Partitions map[int32]int64
+}
+
+// ParseKafkaOffset in one of the formats:
+//
+//   - `earliest` or `latest`;
+//   - relative, e.g. `+200` for 200 messages after the oldest one and `-100` for the last 100 messages;
+//   - timestamp, e.g. `@2000-01-02T03:04:05.006Z`, in the RFC 3339 format;
+//   - absolute, e.g. `1500` for every partition or `0:1500,1:1200` for each partition.
+//
+// An empty value is an OffsetDefault.
+func ParseKafkaOffset(value string) (KafkaOffset, error) {
+	value = strings.TrimSpace(value)
+	switch {
+	case value == "":
+		return KafkaOffset{Kind: OffsetDefault}, nil
+	case value == "earliest":
+		return KafkaOffset{Kind: OffsetEarliest}, nil
+	case value == "latest":
+		return KafkaOffset{Kind: OffsetLatest}, nil
+	case strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-"):
+		relative, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return KafkaOffset{}, fmt.Errorf("invalid relative offset %s", value)
+		}
+		return KafkaOffset{Kind: OffsetRelative, Value: relative}, nil
+	case strings.HasPrefix(value, "@"):
+		timestamp, err := time.Parse(time.RFC3339Nano, value[1:])
+		if err != nil {
+			return KafkaOffset{}, fmt.Errorf("invalid timestamp offset %s; %w", value, err)
+		}
+		return KafkaOffset{Kind: OffsetTimestamp, Timestamp: timestamp}, nil
+	case strings.Contains(value, ":"):
+		partitions := make(map[int32]int64)
+		for _, item := range strings.Split(value, ",") {
+			partition, offset, _ := strings.Cut(strings.TrimSpace(item), ":")
+			p, err := strconv.ParseInt(partition, 10, 32)
+			if err != nil || p < 0 {
+				return KafkaOffset{}, fmt.Errorf("invalid partition %s in offset %s", partition, value)
+			}
+			o, err := strconv.ParseInt(offset, 10, 64)
+			if err != nil || o < 0 {
+				return KafkaOffset{}, fmt.Errorf("invalid offset %s of partition %d", offset, p)
+			}
+			if _, found := partitions[int32(p)]; found {
+				return KafkaOffset{}, fmt.Errorf("duplicate partition %d in offset %s", p, value)
+			}
+			partitions[int32(p)] = o
+		}
+		return KafkaOffset{Kind: OffsetAbsolute, Partitions: partitions}, nil
+	default:
+		offset, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || offset < 0 {
+			return KafkaOffset{}, fmt.Errorf("invalid offset %s", value)
+		}
+		return KafkaOffset{Kind: OffsetAbsolute, Value: offset}, nil
+	}
+}
+
 // KafkaConsumer ...
 type KafkaConsumer struct {
 	ResourceName string `json:"arn"`
@@ -59,10 +178,12 @@ type KafkaConsumer struct {
 	Topic string `json:"topic"`
 	// Group is the consumer group name.
 	Group string `json:"group"`
-	// InitialOffset can be relative (-100, +200) or timestamp (@2000-01-02T03:04:05.006Z).
-	// This parameter is dependent on driver support.
+	// InitialOffset can be earliest, latest, relative (-100, +200), timestamp (@2000-01-02T03:04:05.006Z) or absolute
+	// (1500 or 0:1500,1:1200), see ParseKafkaOffset. Using it is dependent on driver support.
 	InitialOffset string `json:"initial_offset"`
 	resource      resources.KafkaCluster
+	rootCAs       *x509.CertPool
+	offset        KafkaOffset
 	complete      bool
 }
 
@@ -72,6 +193,12 @@ func (cfg *KafkaConsumer) Bootstrap(provider resources.Provider) error {
 	if err := cfg.resource.Validate(); err != nil {
 		return fmt.Errorf("invalid kafka consumer resource %s; %w", cfg.ResourceName, err)
 	}
+	offset, err := ParseKafkaOffset(cfg.InitialOffset)
+	if err != nil {
+		return fmt.Errorf("invalid kafka consumer initial offset; %w", err)
+	}
+	cfg.offset = offset
+	cfg.rootCAs = rootCAsOf(provider)
 	cfg.complete = true
 
 	return nil
@@ -90,6 +217,11 @@ func (cfg KafkaConsumer) Resource() resources.KafkaCluster {
 	return cfg.resource
 }
 
+// Offset from which to start consuming, parsed from the InitialOffset by Bootstrap.
+func (cfg KafkaConsumer) Offset() KafkaOffset {
+	return cfg.offset
+}
+
 // TopicName for this configuration which includes any prefix/suffix specified in the infra resource.
 func (cfg KafkaConsumer) TopicName() string {
 	return cfg.resource.TopicNameFor(cfg.Topic)
@@ -114,11 +246,45 @@ func (cfg KafkaConsumer) GroupNameFor(group string) string {
 	return cfg.resource.GroupPrefix + group + cfg.resource.GroupSuffix
 }
 
+// ClientConfig with the settings of the consumer and the security settings of the cluster.
+func (cfg KafkaConsumer) ClientConfig() (KafkaClientConfig, error) {
+	if !cfg.complete {
+		return KafkaClientConfig{}, ErrConfigNotBootstrapped
+	}
+	client, err := kafkaClientConfig(cfg.resource, cfg.rootCAs)
+	if err != nil {
+		return KafkaClientConfig{}, fmt.Errorf("invalid kafka consumer resource %s; %w", cfg.ResourceName, err)
+	}
+	client.Topic = cfg.TopicName()
+	client.Group = cfg.GroupName()
+	client.Offset = cfg.offset
+	return client, nil
+}
+
+// LibrdkafkaConfig with the properties of the consumer for librdkafka based clients, such as confluent-kafka-go, e.g.
+// `kafka.NewConsumer(&kafka.ConfigMap{...})` with every property returned. Only earliest and latest initial offsets
+// are properties, set as `auto.offset.reset`, the others have to be used when assigning partitions.
+func (cfg KafkaConsumer) LibrdkafkaConfig() (map[string]interface{}, error) {
+	if !cfg.complete {
+		return nil, ErrConfigNotBootstrapped
+	}
+	properties := librdkafkaConfig(cfg.resource)
+	properties["group.id"] = cfg.GroupName()
+	switch cfg.offset.Kind {
+	case OffsetEarliest:
+		properties["auto.offset.reset"] = "earliest"
+	case OffsetLatest:
+		properties["auto.offset.reset"] = "latest"
+	}
+	return properties, nil
+}
+
 // KafkaProducer ...
 type KafkaProducer struct {
 	ResourceName string `json:"arn"`
 	Topic        string `json:"topic"`
 	resource     resources.KafkaCluster
+	rootCAs      *x509.CertPool
 	complete     bool
 }
 
@@ -128,6 +294,7 @@ func (cfg *KafkaProducer) Bootstrap(provider resources.Provider) error {
 	if err := cfg.resource.Validate(); err != nil {
 		return fmt.Errorf("invalid kafka producer resource; %w", err)
 	}
+	cfg.rootCAs = rootCAsOf(provider)
 	cfg.complete = true
 
 	return nil
@@ -157,3 +324,90 @@ func (cfg KafkaProducer) TopicName() string {
 func (cfg KafkaProducer) TopicNameFor(topic string) string {
 	return cfg.resource.TopicNameFor(topic)
 }
+
+// ClientConfig with the settings of the producer and the security settings of the cluster.
+func (cfg KafkaProducer) ClientConfig() (KafkaClientConfig, error) {
+	if !cfg.complete {
+		return KafkaClientConfig{}, ErrConfigNotBootstrapped
+	}
+	client, err := kafkaClientConfig(cfg.resource, cfg.rootCAs)
+	if err != nil {
+		return KafkaClientConfig{}, fmt.Errorf("invalid kafka producer resource %s; %w", cfg.ResourceName, err)
+	}
+	client.Topic = cfg.TopicName()
+	return client, nil
+}
+
+// LibrdkafkaConfig with the properties of the producer for librdkafka based clients, such as confluent-kafka-go, e.g.
+// `kafka.NewProducer(&kafka.ConfigMap{...})` with every property returned.
+func (cfg KafkaProducer) LibrdkafkaConfig() (map[string]interface{}, error) {
+	if !cfg.complete {
+		return nil, ErrConfigNotBootstrapped
+	}
+	return librdkafkaConfig(cfg.resource), nil
+}
+
+func kafkaClientConfig(cluster resources.KafkaCluster, rootCAs *x509.CertPool) (KafkaClientConfig, error) {
+	client := KafkaClientConfig{Brokers: cluster.Brokers}
+
+	protocol := cluster.SecurityProtocol()
+	if protocol == resources.KafkaSSL || protocol == resources.KafkaSASLSSL {
+		tlsConfig, err := clientTLSConfig(rootCAs, cluster.Security.CAFile, cluster.Security.CertFile, cluster.Security.KeyFile)
+		if err != nil {
+			return KafkaClientConfig{}, err
+		}
+		client.TLS = tlsConfig
+	}
+
+	switch mechanism := cluster.SASLMechanism(); mechanism {
+	case "":
+	case resources.KafkaOAuthBearer:
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{RootCAs: rootCAs}
+		tokens := &tokenSource{
+			credentials: cluster.Security.OAuth2,
+			client:      &http.Client{Transport: transport},
+		}
+		client.SASL = &KafkaSASL{Mechanism: mechanism, Token: tokens.Token}
+	default:
+		client.SASL = &KafkaSASL{Mechanism: mechanism, Username: cluster.Username, Password: cluster.Password}
+	}
+	return client, nil
+}
+
+// librdkafkaConfig with the brokers and security properties of the cluster. Without a CA file librdkafka trusts the
+// certificates of the system, not the ones of the Provider, and the audience of OAuth2 credentials is not supported.
+func librdkafkaConfig(cluster resources.KafkaCluster) map[string]interface{} {
+	properties := map[string]interface{}{
+		"bootstrap.servers": strings.Join(cluster.Brokers, ","),
+		"security.protocol": strings.ToLower(cluster.SecurityProtocol()),
+	}
+	for property, value := range map[string]string{
+		"ssl.ca.location":          cluster.Security.CAFile,
+		"ssl.certificate.location": cluster.Security.CertFile,
+		"ssl.key.location":         cluster.Security.KeyFile,
+	} {
+		if value != "" {
+			properties[property] = value
+		}
+	}
+
+	switch mechanism := cluster.SASLMechanism(); mechanism {
+	case "":
+	case resources.KafkaOAuthBearer:
+		oauth2 := cluster.Security.OAuth2
+		properties["sasl.mechanisms"] = mechanism
+		properties["sasl.oauthbearer.method"] = "oidc"
+		properties["sasl.oauthbearer.token.endpoint.url"] = oauth2.TokenURL
+		properties["sasl.oauthbearer.client.id"] = oauth2.ClientID
+		properties["sasl.oauthbearer.client.secret"] = oauth2.ClientSecret
+		if len(oauth2.Scopes) > 0 {
+			properties["sasl.oauthbearer.scope"] = strings.Join(oauth2.Scopes, " ")
+		}
+	default:
+		properties["sasl.mechanisms"] = mechanism
+		properties["sasl.username"] = cluster.Username
+		properties["sasl.password"] = cluster.Password
+	}
+	return properties
+}
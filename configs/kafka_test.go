@@ -2,10 +2,12 @@ package configs_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/vredens/infrastructure"
 	"github.com/vredens/infrastructure/configs"
+	"github.com/vredens/infrastructure/resources"
 )
 
 func TestKafkaResources(t *testing.T) {
@@ -94,3 +96,193 @@ func TestKafkaResources(t *testing.T) {
 		assert.Error(t, cfg.Kafka.Test.Bootstrap(provider))
 	})
 }
+
+func TestKafkaSecurity(t *testing.T) {
+	provider, err := infrastructure.NewProvider(infrastructure.ProviderSettings{
+		EnvName:       "kafka-tests",
+		SystemName:    "tests",
+		ComponentName: "test",
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	var cfg struct {
+		Kafka struct {
+			SCRAM            configs.KafkaConsumer `json:"scram-ssl"`
+			MTLS             configs.KafkaProducer `json:"mtls"`
+			OAuth            configs.KafkaProducer `json:"oauth"`
+			Plaintext        configs.KafkaProducer `json:"plaintext"`
+			UsernameOnly     configs.KafkaProducer `json:"username-only"`
+			InvalidMechanism configs.KafkaProducer `json:"invalid-mechanism"`
+		}
+	}
+	if !assert.NoError(t, provider.LoadConfig("kafka", &cfg)) {
+		t.FailNow()
+	}
+
+	_, err = cfg.Kafka.SCRAM.ClientConfig()
+	assert.ErrorIs(t, err, configs.ErrConfigNotBootstrapped)
+	assert.Error(t, cfg.Kafka.InvalidMechanism.Bootstrap(provider))
+
+	t.Run("sasl-ssl", func(t *testing.T) {
+		if !assert.NoError(t, cfg.Kafka.SCRAM.Bootstrap(provider)) {
+			t.FailNow()
+		}
+		client, err := cfg.Kafka.SCRAM.ClientConfig()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, []string{"broker-1:9096", "broker-2:9096"}, client.Brokers)
+		assert.Equal(t, "my-topic", client.Topic)
+		assert.Equal(t, "my.group", client.Group)
+		if assert.NotNil(t, client.TLS) {
+			assert.NotNil(t, client.TLS.RootCAs)
+			assert.NotSame(t, provider.Certs().RootCAs(), client.TLS.RootCAs)
+		}
+		if assert.NotNil(t, client.SASL) {
+			assert.Equal(t, "SCRAM-SHA-512", client.SASL.Mechanism)
+			assert.Equal(t, "test-user-s", client.SASL.Username)
+			assert.Equal(t, "test-pass-s", client.SASL.Password)
+		}
+
+		properties, err := cfg.Kafka.SCRAM.LibrdkafkaConfig()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, map[string]interface{}{
+			"bootstrap.servers": "broker-1:9096,broker-2:9096",
+			"security.protocol": "sasl_ssl",
+			"ssl.ca.location":   "testdata/certs/ca.pem",
+			"sasl.mechanisms":   "SCRAM-SHA-512",
+			"sasl.username":     "test-user-s",
+			"sasl.password":     "test-pass-s",
+			"group.id":          "my.group",
+		}, properties)
+	})
+
+	t.Run("ssl", func(t *testing.T) {
+		if !assert.NoError(t, cfg.Kafka.MTLS.Bootstrap(provider)) {
+			t.FailNow()
+		}
+		client, err := cfg.Kafka.MTLS.ClientConfig()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Nil(t, client.SASL)
+		if assert.NotNil(t, client.TLS) {
+			assert.Same(t, provider.Certs().RootCAs(), client.TLS.RootCAs)
+			assert.Len(t, client.TLS.Certificates, 1)
+		}
+	})
+
+	t.Run("oauthbearer", func(t *testing.T) {
+		if !assert.NoError(t, cfg.Kafka.OAuth.Bootstrap(provider)) {
+			t.FailNow()
+		}
+		client, err := cfg.Kafka.OAuth.ClientConfig()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		if assert.NotNil(t, client.SASL) {
+			assert.Equal(t, "OAUTHBEARER", client.SASL.Mechanism)
+			assert.NotNil(t, client.SASL.Token)
+		}
+		properties, err := cfg.Kafka.OAuth.LibrdkafkaConfig()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, "oidc", properties["sasl.oauthbearer.method"])
+		assert.Equal(t, "https://auth.example.com/token", properties["sasl.oauthbearer.token.endpoint.url"])
+		assert.Equal(t, "kafka", properties["sasl.oauthbearer.scope"])
+	})
+
+	t.Run("plaintext", func(t *testing.T) {
+		if !assert.NoError(t, cfg.Kafka.Plaintext.Bootstrap(provider)) {
+			t.FailNow()
+		}
+		client, err := cfg.Kafka.Plaintext.ClientConfig()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Nil(t, client.TLS)
+		if assert.NotNil(t, client.SASL) {
+			assert.Equal(t, "PLAIN", client.SASL.Mechanism)
+		}
+		assert.Equal(t, "tpa-my-topic-tsa", client.Topic)
+	})
+
+	t.Run("username-only", func(t *testing.T) {
+		if !assert.NoError(t, cfg.Kafka.UsernameOnly.Bootstrap(provider)) {
+			t.FailNow()
+		}
+		assert.Equal(t, resources.KafkaPlaintext, cfg.Kafka.UsernameOnly.Resource().SecurityProtocol())
+		client, err := cfg.Kafka.UsernameOnly.ClientConfig()
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		assert.Nil(t, client.TLS)
+		assert.Nil(t, client.SASL)
+	})
+}
+
+func TestKafkaConsumerOffset(t *testing.T) {
+	provider, err := infrastructure.NewProvider(infrastructure.ProviderSettings{
+		EnvName:       "kafka-tests",
+		SystemName:    "tests",
+		ComponentName: "test",
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	var cfg struct {
+		Kafka struct {
+			Latest  configs.KafkaConsumer `json:"offset-latest"`
+			Invalid configs.KafkaConsumer `json:"offset-invalid"`
+		}
+	}
+	if !assert.NoError(t, provider.LoadConfig("kafka", &cfg)) {
+		t.FailNow()
+	}
+
+	assert.Error(t, cfg.Kafka.Invalid.Bootstrap(provider))
+	if !assert.NoError(t, cfg.Kafka.Latest.Bootstrap(provider)) {
+		t.FailNow()
+	}
+	assert.Equal(t, configs.KafkaOffset{Kind: configs.OffsetLatest}, cfg.Kafka.Latest.Offset())
+	properties, err := cfg.Kafka.Latest.LibrdkafkaConfig()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "latest", properties["auto.offset.reset"])
+	}
+	client, err := cfg.Kafka.Latest.ClientConfig()
+	if assert.NoError(t, err) {
+		assert.Equal(t, configs.OffsetLatest, client.Offset.Kind)
+	}
+}
+
+func TestParseKafkaOffset(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected configs.KafkaOffset
+	}{
+		{value: "", expected: configs.KafkaOffset{Kind: configs.OffsetDefault}},
+		{value: "earliest", expected: configs.KafkaOffset{Kind: configs.OffsetEarliest}},
+		{value: "latest", expected: configs.KafkaOffset{Kind: configs.OffsetLatest}},
+		{value: "-100", expected: configs.KafkaOffset{Kind: configs.OffsetRelative, Value: -100}},
+		{value: "+200", expected: configs.KafkaOffset{Kind: configs.OffsetRelative, Value: 200}},
+		{value: "@2000-01-02T03:04:05.006Z", expected: configs.KafkaOffset{Kind: configs.OffsetTimestamp, Timestamp: time.Date(2000, 1, 2, 3, 4, 5, 6000000, time.UTC)}},
+		{value: "1500", expected: configs.KafkaOffset{Kind: configs.OffsetAbsolute, Value: 1500}},
+		{value: "0:1500, 1:1200", expected: configs.KafkaOffset{Kind: configs.OffsetAbsolute, Partitions: map[int32]int64{0: 1500, 1: 1200}}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			offset, err := configs.ParseKafkaOffset(tc.value)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, offset)
+		})
+	}
+
+	for _, value := range []string{"oldest", "-1O0", "+", "@2000-01-02", "-5000000000000000000000", "0:1,0:2", "a:1", "0:-1", "-1:5", "1.5"} {
+		_, err := configs.ParseKafkaOffset(value)
+		assert.Error(t, err, value)
+	}
+}
@@ -0,0 +1,107 @@
+package configs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/vredens/infrastructure/resources"
+)
+
+// tokenExpiryMargin is how long before their expiry tokens are refreshed, so they don't expire while in flight. Tokens
+// lasting less than twice the margin are refreshed halfway through their lifetime instead.
+const tokenExpiryMargin = 30 * time.Second
+
+// oauth2Token is a token of the OAuth2 client credentials grant.
+type oauth2Token struct {
+	value   string
+	expires time.Time
+}
+
+// tokenSource fetches tokens from an OAuth2 authorisation server and caches them until shortly before they expire, or
+// until rejected if they have no expiry. Concurrent requests for a token wait for a single fetch.
+type tokenSource struct {
+	credentials resources.OAuth2Credentials
+	client      *http.Client
+	mu          sync.Mutex
+	token       oauth2Token
+}
+
+// Token which is cached or, if there is none or it is about to expire, fetched.
+func (src *tokenSource) Token(ctx context.Context) (string, error) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+	if src.token.value != "" && (src.token.expires.IsZero() || time.Now().Before(src.token.expires)) {
+		return src.token.value, nil
+	}
+	token, err := src.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	src.token = token
+	return token.value, nil
+}
+
+// Invalidate the cached token, if it is still the one given, forcing the next call to Token to fetch a new one.
+func (src *tokenSource) Invalidate(value string) {
+	src.mu.Lock()
+	defer src.mu.Unlock()
+	if src.token.value == value {
+		src.token = oauth2Token{}
+	}
+}
+
+func (src *tokenSource) fetch(ctx context.Context) (oauth2Token, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(src.credentials.Scopes) > 0 {
+		form.Set("scope", strings.Join(src.credentials.Scopes, " "))
+	}
+	if src.credentials.Audience != "" {
+		form.Set("audience", src.credentials.Audience)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, src.credentials.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return oauth2Token{}, fmt.Errorf("failed to create oauth2 token request; %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	// credentials are form encoded before being used for basic authentication, as defined by RFC 6749.
+	req.SetBasicAuth(url.QueryEscape(src.credentials.ClientID), url.QueryEscape(src.credentials.ClientSecret))
+
+	res, err := src.client.Do(req)
+	if err != nil {
+		return oauth2Token{}, fmt.Errorf("failed to request oauth2 token; %w", err)
+	}
+	defer res.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(res.Body, 1<<20))
+	if err != nil {
+		return oauth2Token{}, fmt.Errorf("failed to read oauth2 token; %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return oauth2Token{}, fmt.Errorf("failed to request oauth2 token; status %d: %s", res.StatusCode, body)
+	}
+
+	var payload struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return oauth2Token{}, fmt.Errorf("failed to decode oauth2 token; %w", err)
+	}
+	if payload.AccessToken == "" {
+		return oauth2Token{}, fmt.Errorf("oauth2 token response without an access_token")
+	}
+
+	token := oauth2Token{value: payload.AccessToken}
+	if payload.ExpiresIn > 0 {
+		lifetime := time.Duration(payload.ExpiresIn) * time.Second
+		token.expires = time.Now().Add(lifetime - min(tokenExpiryMargin, lifetime/2))
+	}
+	return token, nil
+}
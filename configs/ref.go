@@ -0,0 +1,76 @@
+package configs
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/vredens/infrastructure/resources"
+)
+
+// Ref is a configuration referencing an infrastructure resource of type T by its ARN. It works for every resource type
+// of the resources.Locator, e.g. `configs.Ref[resources.Postgres]`, and for the pointer types of types registered with
+// resources.RegisterType, e.g. `configs.Ref[*MongoDB]`.
+//
+// Instead of an ARN, the resource can be selected with a tag query, e.g. `datacenter={{ .Env.REGION }},!state=discontinued`,
+// which must match exactly one resource of type T. Check resources.Locator.Find for the query syntax.
+type Ref[T resources.Validator] struct {
+	ResourceName string `json:"arn"`
+	Query        string `json:"query"`
+	resource     T
+	// found is the ARN of the resource matching the Query.
+	found    string
+	complete bool
+}
+
+// Bootstrap the configuration by locating and validating the infrastructure resource. It can be called again, such as
+// after reloading the configuration, in which case the resource matching the Query is searched for again.
+func (cfg *Ref[T]) Bootstrap(provider resources.Provider) error {
+	cfg.complete = false
+	if cfg.Query != "" && (cfg.ResourceName == "" || cfg.ResourceName == cfg.found) {
+		arn, err := cfg.find(provider.Locator())
+		if err != nil {
+			return err
+		}
+		cfg.ResourceName, cfg.found = arn, arn
+	}
+	cfg.resource = resources.Locate[T](provider.Locator(), cfg.ResourceName)
+	if err := cfg.resource.Validate(); err != nil {
+		return fmt.Errorf("invalid infrastructure resource for %s; %w", cfg.ResourceName, err)
+	}
+	cfg.complete = true
+
+	return nil
+}
+
+// Validate returns an error if the configuration is NOT valid.
+func (cfg Ref[T]) Validate() error {
+	if !cfg.complete {
+		return ErrConfigNotBootstrapped
+	}
+	return cfg.resource.Validate()
+}
+
+// Resource with the infrastructure configuration. Requires previous call to Bootstrap.
+func (cfg Ref[T]) Resource() T {
+	return cfg.resource
+}
+
+// find the ARN of the only resource of type T matching the query.
+func (cfg Ref[T]) find(locator *resources.Locator) (string, error) {
+	t := reflect.TypeOf(&cfg.resource).Elem()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	found, err := locator.Find(cfg.Query, t.Name())
+	if err != nil {
+		return "", err
+	}
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("no %s resource matches %q; %w", t.Name(), cfg.Query, resources.ErrResourceNotFound)
+	case 1:
+		return found[0].ARN, nil
+	default:
+		return "", fmt.Errorf("%d %s resources match %q, expected one", len(found), t.Name(), cfg.Query)
+	}
+}
@@ -0,0 +1,92 @@
+package configs_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vredens/infrastructure"
+	"github.com/vredens/infrastructure/configs"
+	"github.com/vredens/infrastructure/resources"
+)
+
+func TestRef(t *testing.T) {
+	os.Setenv("PSQL_USER", "username")
+	os.Setenv("PSQL_PASS", "password")
+	provider, err := infrastructure.NewProvider(infrastructure.ProviderSettings{
+		EnvName:       "pg-tests",
+		SystemName:    "tests",
+		ComponentName: "test",
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	var cfg struct {
+		Test configs.Ref[resources.Postgres] `json:"valid-1"`
+		Type configs.Ref[resources.Redis]    `json:"valid-2"`
+	}
+	if !assert.NoError(t, provider.LoadConfig("pg", &cfg)) {
+		t.FailNow()
+	}
+	assert.ErrorIs(t, cfg.Test.Validate(), configs.ErrConfigNotBootstrapped)
+	if assert.NoError(t, cfg.Test.Bootstrap(provider)) {
+		assert.NoError(t, cfg.Test.Validate())
+		assert.Equal(t, "localhost", cfg.Test.Resource().Host)
+		assert.Equal(t, uint16(5432), cfg.Test.Resource().Port)
+		assert.Equal(t, "username", cfg.Test.Resource().User)
+
+		// configurations are bootstrapped again after being reloaded.
+		if assert.NoError(t, provider.LoadConfig("pg", &cfg)) && assert.NoError(t, cfg.Test.Bootstrap(provider)) {
+			assert.Equal(t, "localhost", cfg.Test.Resource().Host)
+		}
+	}
+	assert.ErrorContains(t, cfg.Type.Bootstrap(provider), "expected arn to contain [redis]")
+}
+
+func TestRefRegistered(t *testing.T) {
+	provider, err := infrastructure.NewProvider(infrastructure.ProviderSettings{
+		EnvName:       "registered-tests",
+		SystemName:    "sys",
+		ComponentName: "comp",
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	var cfg struct {
+		Events  configs.Ref[*MongoDB] `json:"events"`
+		Missing configs.Ref[*MongoDB] `json:"missing"`
+	}
+	if !assert.NoError(t, provider.LoadConfig("registered", &cfg)) {
+		t.FailNow()
+	}
+	if assert.NoError(t, cfg.Events.Bootstrap(provider)) {
+		assert.Equal(t, "events", cfg.Events.Resource().Database)
+	}
+	assert.ErrorIs(t, cfg.Missing.Bootstrap(provider), resources.ErrResourceNotFound)
+}
+
+func TestRefQuery(t *testing.T) {
+	provider, err := infrastructure.NewProvider(infrastructure.ProviderSettings{
+		EnvName:       "registered-tests",
+		SystemName:    "sys",
+		ComponentName: "comp",
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	events := configs.Ref[*MongoDB]{Query: "tier=2"}
+	if assert.NoError(t, events.Bootstrap(provider)) {
+		assert.Equal(t, "arn://storage/mongodb/events", events.ResourceName)
+		assert.Equal(t, "events", events.Resource().Database)
+	}
+	assert.NoError(t, events.Bootstrap(provider))
+
+	missing := configs.Ref[*MongoDB]{Query: "tier=9"}
+	assert.ErrorIs(t, missing.Bootstrap(provider), resources.ErrResourceNotFound)
+
+	many := configs.Ref[*MongoDB]{Query: "!tier=9"}
+	assert.ErrorContains(t, many.Bootstrap(provider), "2 MongoDB resources match")
+}
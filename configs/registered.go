@@ -0,0 +1,9 @@
+package configs
+
+import "github.com/vredens/infrastructure/resources"
+
+// Registered configuration for resources of a type registered with resources.RegisterType, where T is the pointer
+// type returned by the registered factory, e.g. `configs.Registered[*MongoDB]`. It is the same as Ref.
+type Registered[T resources.Registrable] struct {
+	Ref[T]
+}
@@ -0,0 +1,97 @@
+package configs_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vredens/infrastructure"
+	"github.com/vredens/infrastructure/configs"
+	"github.com/vredens/infrastructure/resources"
+)
+
+type MongoDB struct {
+	resources.Resource
+	URI      string `json:"uri"`
+	Database string `json:"database"`
+}
+
+func (r *MongoDB) Validate() error {
+	if err := r.Resource.Validate(); err != nil {
+		return err
+	}
+	if r.URI == "" {
+		return errors.New("mongodb uri can not be empty")
+	}
+	return nil
+}
+
+type Vendor struct {
+	resources.Resource
+	URI string `json:"uri"`
+}
+
+func init() {
+	resources.RegisterType("storage/mongodb", func() resources.Registrable { return &MongoDB{} })
+	resources.RegisterType("vendors/acme", func() resources.Registrable { return &Vendor{} })
+}
+
+func TestRegistered(t *testing.T) {
+	provider, err := infrastructure.NewProvider(infrastructure.ProviderSettings{
+		EnvName:       "registered-tests",
+		SystemName:    "sys",
+		ComponentName: "comp",
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	var config struct {
+		Events  configs.Registered[*MongoDB] `json:"events"`
+		Broken  configs.Registered[*MongoDB] `json:"broken"`
+		Missing configs.Registered[*MongoDB] `json:"missing"`
+		Vendor  configs.Registered[*MongoDB] `json:"vendor"`
+	}
+	if !assert.NoError(t, provider.LoadConfig("registered", &config)) {
+		t.FailNow()
+	}
+
+	assert.ErrorIs(t, config.Events.Validate(), configs.ErrConfigNotBootstrapped)
+	if assert.NoError(t, config.Events.Bootstrap(provider)) {
+		assert.NoError(t, config.Events.Validate())
+		assert.Equal(t, "mongodb://registered-tests-mongo:27017", config.Events.Resource().URI)
+		assert.Equal(t, "events", config.Events.Resource().Database)
+		assert.Equal(t, []string{"tier:2"}, config.Events.Resource().Tags)
+	}
+	assert.ErrorContains(t, config.Broken.Bootstrap(provider), "mongodb uri can not be empty")
+	assert.ErrorIs(t, config.Missing.Bootstrap(provider), resources.ErrResourceNotFound)
+	assert.ErrorContains(t, config.Vendor.Bootstrap(provider), "is a *configs_test.Vendor, not a *configs_test.MongoDB")
+
+	vendor, ok := provider.Locator().LocateRegistered("arn://vendors/acme/main").(*Vendor)
+	if assert.True(t, ok) {
+		assert.Equal(t, "https://acme.example.com", vendor.URI)
+	}
+	assert.Error(t, provider.Locator().LocateRegistered("arn://vendors/unknown/main").Validate())
+
+	var arns []string
+	for _, entry := range provider.Locator().All() {
+		arns = append(arns, entry.ARN+" "+entry.Kind)
+	}
+	assert.Equal(t, []string{
+		"arn://storage/mongodb/broken MongoDB",
+		"arn://storage/mongodb/events MongoDB",
+		"arn://storage/redis/cache Redis",
+		"arn://vendors/acme/main Vendor",
+	}, arns)
+	assert.ErrorContains(t, provider.Locator().ValidateAll(), "arn://storage/mongodb/broken: mongodb uri can not be empty")
+
+	assert.Panics(t, func() {
+		resources.RegisterType("storage/mongodb", func() resources.Registrable { return &MongoDB{} })
+	})
+	assert.Panics(t, func() {
+		resources.RegisterType("storage/postgres", func() resources.Registrable { return &MongoDB{} })
+	})
+	assert.Panics(t, func() {
+		resources.RegisterType("storage", func() resources.Registrable { return &MongoDB{} })
+	})
+}
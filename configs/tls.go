@@ -0,0 +1,44 @@
+package configs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"github.com/vredens/infrastructure/lib/certs"
+	"github.com/vredens/infrastructure/resources"
+)
+
+// rootCAsOf the provider, which are the custom certificates of the infrastructure Provider. Nil if the provider has none.
+func rootCAsOf(provider resources.Provider) *x509.CertPool {
+	if provided, ok := provider.(interface{ Certs() certs.Certs }); ok {
+		return provided.Certs().RootCAs()
+	}
+	return nil
+}
+
+// clientTLSConfig trusting the certificates in the CA file, if set, or the rootCAs, and with the client certificate, if
+// set, for mutual TLS.
+func clientTLSConfig(rootCAs *x509.CertPool, caFile, certFile, keyFile string) (*tls.Config, error) {
+	if caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca file; %w", err)
+		}
+		rootCAs = x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in ca file %s", caFile)
+		}
+	}
+
+	config := &tls.Config{RootCAs: rootCAs}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate; %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	return config, nil
+}
@@ -0,0 +1,99 @@
+// Package connect opens clients for the resources of application configurations, applying every setting of the
+// configuration and of the infrastructure resource so that services don't have to.
+package connect
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/vredens/infrastructure/configs"
+)
+
+// DefaultPostgresDriver is the database/sql driver used by OpenPostgres unless another is given with
+// WithPostgresDriver. It is the name lib/pq registers.
+const DefaultPostgresDriver = "postgres"
+
+// PostgresOption changes how OpenPostgres connects.
+type PostgresOption func(*postgresOptions)
+
+type postgresOptions struct {
+	driver    string
+	connector func(conn configs.PostgresConnection) (driver.Connector, error)
+}
+
+// WithPostgresDriver sets the name of the database/sql driver opened with the DSN, e.g. `postgres` for lib/pq or `pgx`
+// for the stdlib package of pgx. The driver must be registered by importing its package.
+func WithPostgresDriver(name string) PostgresOption {
+	return func(opts *postgresOptions) {
+		opts.driver = name
+	}
+}
+
+// WithPostgresConnector sets the function creating the connector used instead of opening a driver with the DSN.
+// Drivers which can't read every TLS setting from the DSN, such as the certificates of the Provider, need one, e.g.
+// for pgx:
+//
+//	connect.WithPostgresConnector(func(conn configs.PostgresConnection) (driver.Connector, error) {
+//		config, err := pgx.ParseConfig(conn.URL)
+//		if err != nil {
+//			return nil, err
+//		}
+//		if config.TLSConfig != nil {
+//			config.TLSConfig = conn.TLSFor(config.Host)
+//		}
+//		for _, fallback := range config.Fallbacks {
+//			if fallback.TLSConfig != nil {
+//				fallback.TLSConfig = conn.TLSFor(fallback.Host)
+//			}
+//		}
+//		return stdlib.GetConnector(*config), nil
+//	})
+func WithPostgresConnector(connector func(conn configs.PostgresConnection) (driver.Connector, error)) PostgresOption {
+	return func(opts *postgresOptions) {
+		opts.connector = connector
+	}
+}
+
+// OpenPostgres opens a connection pool to the primary of the Postgres resource of the bootstrapped configuration.
+// The pool is sized and its connections expire according to the configuration params. If the params have `ping` set
+// the database is pinged before returning, within the context.
+//
+// The driver is DefaultPostgresDriver unless changed by the options. The credentials used are the ones of the resource
+// located, which are the ones of the role when the ARN has one.
+func OpenPostgres(ctx context.Context, cfg configs.Postgres, options ...PostgresOption) (*sql.DB, error) {
+	opts := postgresOptions{driver: DefaultPostgresDriver}
+	for _, option := range options {
+		option(&opts)
+	}
+
+	conn, err := cfg.Connection()
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure postgres connection for %s; %w", cfg.ResourceName, err)
+	}
+
+	var db *sql.DB
+	if opts.connector != nil {
+		connector, err := opts.connector(conn)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create postgres connector for %s; %w", cfg.ResourceName, err)
+		}
+		db = sql.OpenDB(connector)
+	} else if db, err = sql.Open(opts.driver, conn.URL); err != nil {
+		return nil, fmt.Errorf("failed to open postgres connection for %s; %w", cfg.ResourceName, err)
+	}
+
+	db.SetMaxIdleConns(cfg.Params.MaxIdleConns)
+	db.SetMaxOpenConns(cfg.Params.MaxOpenConns)
+	db.SetConnMaxLifetime(cfg.Params.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.Params.ConnMaxIdleTime)
+
+	if cfg.Params.Ping {
+		if err := db.PingContext(ctx); err != nil {
+			db.Close()
+			return nil, fmt.Errorf("failed to ping postgres %s; %w", cfg.ResourceName, err)
+		}
+	}
+	return db, nil
+}
@@ -0,0 +1,120 @@
+package infrastructure
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// configExtensions supported for infrastructure and application configuration files.
+// When files with the same name exist in the same folder in more than one format, the first one in this list is used.
+var configExtensions = []string{"json", "yaml", "yml", "toml", "hcl"}
+
+// findConfigFile returns the path of the first configuration file with the given name found in the folders.
+// Folders are searched in order and, for each folder, extensions are checked in the order of configExtensions.
+func findConfigFile(folders []string, name string) (string, bool) {
+	for _, folder := range folders {
+		for _, ext := range configExtensions {
+			path := filepath.Join(folder, name+"."+ext)
+			if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+				return path, true
+			}
+		}
+	}
+	return "", false
+}
+
+// formatOf the configuration file, as detected from its extension. Defaults to json.
+func formatOf(path string) string {
+	switch ext := strings.TrimPrefix(filepath.Ext(path), "."); ext {
+	case "yaml", "yml":
+		return "yaml"
+	case "toml", "hcl":
+		return ext
+	default:
+		return "json"
+	}
+}
+
+// newLoader creates a configuration loader for the format.
+func newLoader(format string) *viper.Viper {
+	loader := viper.New()
+	loader.SetConfigType(format)
+	return loader
+}
+
+// parseDocument in the given format into a generic document. JSON, YAML and TOML documents keep the case of their keys
+// and their explicit null values, for merging and inspecting them, while HCL documents are parsed by viper which lower
+// cases their keys. Decoding a document into a structure, see decodeDocument, ignores the case of keys either way.
+func parseDocument(format string, content []byte) (map[string]interface{}, error) {
+	doc := make(map[string]interface{})
+	switch format {
+	case "json":
+		if err := json.Unmarshal(content, &doc); err != nil {
+			return nil, err
+		}
+	case "yaml":
+		if err := yaml.Unmarshal(content, &doc); err != nil {
+			return nil, err
+		}
+	case "toml":
+		if err := toml.Unmarshal(content, &doc); err != nil {
+			return nil, err
+		}
+	default:
+		loader := newLoader(format)
+		if err := loader.ReadConfig(strings.NewReader(string(content))); err != nil {
+			return nil, err
+		}
+		doc = loader.AllSettings()
+		if format == "hcl" {
+			doc = flattenBlocks(doc).(map[string]interface{})
+		}
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("%s document is not an object", format)
+	}
+	return doc, nil
+}
+
+// flattenBlocks replaces the blocks of an HCL document, which are decoded as lists of objects, with their object when
+// there is a single one so that `params { ... }` decodes like `params = { ... }`. Repeated blocks remain lists.
+func flattenBlocks(value interface{}) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			value[key] = flattenBlocks(item)
+		}
+		return value
+	case []map[string]interface{}:
+		if len(value) == 1 {
+			return flattenBlocks(value[0])
+		}
+		list := make([]interface{}, len(value))
+		for i, item := range value {
+			list[i] = flattenBlocks(item)
+		}
+		return list
+	case []interface{}:
+		for i, item := range value {
+			value[i] = flattenBlocks(item)
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+// jsonDecoding makes decoding use the json tags of the structures and treat the fields of embedded structures, such as
+// resources.Resource, as fields of the structure embedding them, just like encoding/json does.
+func jsonDecoding(cfg *mapstructure.DecoderConfig) {
+	cfg.TagName = "json"
+	cfg.Squash = true
+}
@@ -0,0 +1,371 @@
+package infrastructure
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/vredens/infrastructure/resources"
+)
+
+// extendsKey is the key used in infrastructure configuration files to extend the configuration of other environments.
+// The value is an environment name, or a list of names, whose configuration is loaded before the one of the
+// environment being loaded.
+const extendsKey = "extends"
+
+// includeKey is the key used in infrastructure configuration files to include other files.
+// The value is a path, or a list of paths, relative to the file where it is used. Paths can be glob patterns.
+const includeKey = "$include"
+
+// infra is an immutable snapshot of the loaded infrastructure configuration.
+// It is replaced as a whole whenever the configuration is reloaded.
+type infra struct {
+	locator resources.Locator
+	// doc is the rendered and merged configuration document the locator was decoded from.
+	doc map[string]interface{}
+	// path of the main configuration file, if any.
+	path string
+	// files contributing to the configuration, in the order they were loaded.
+	files []string
+	// watched are the files and folders whose changes trigger a reload.
+	watched []string
+	// chain of environments loaded, starting with the furthest ancestor and ending with the current environment.
+	chain []string
+	// overrides applied from environment variables.
+	overrides []EnvOverride
+	// aliases mapped to the ARN of the resources they resolve to.
+	aliases map[string]string
+}
+
+func (snapshot *infra) inChain(env string) bool {
+	for _, loaded := range snapshot.chain {
+		if loaded == env {
+			return true
+		}
+	}
+	return false
+}
+
+// loadInfra reads, renders and merges every infrastructure configuration file into a new snapshot.
+func (provider *Provider) loadInfra() (*infra, error) {
+	snapshot := &infra{}
+	doc, err := provider.loadEnvInfra(provider.settings.EnvName, snapshot, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create infra; %w", err)
+	}
+
+	if err := selectVariants(doc, nil, provider.settings.zones()); err != nil {
+		return nil, fmt.Errorf("failed to create infra; %w", err)
+	}
+	if doc, snapshot.aliases, err = resolveReferences(doc); err != nil {
+		return nil, fmt.Errorf("failed to create infra; %w", err)
+	}
+	snapshot.doc = doc
+	snapshot.locator.SetProvider(provider)
+	if err := decodeDocument(doc, &snapshot.locator); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal infrastructure configuration; %w", err)
+	}
+	if snapshot.overrides, err = provider.applyEnvOverrides("", &snapshot.locator); err != nil {
+		return nil, fmt.Errorf("failed to create infra; %w", err)
+	}
+	if provider.settings.StrictValidation {
+		if err := snapshot.locator.ValidateAll(); err != nil {
+			return nil, fmt.Errorf("invalid infrastructure configuration; %w", err)
+		}
+	}
+
+	provider.mu.Lock()
+	for _, path := range snapshot.watched {
+		provider.infraSums[path] = checksum(path)
+	}
+	provider.mu.Unlock()
+	return snapshot, nil
+}
+
+// EffectiveInfra returns the infrastructure configuration document of the current Locator, after rendering and merging
+// every file but before applying environment variable overrides. It is meant for debugging configurations and may
+// contain secrets.
+func (provider *Provider) EffectiveInfra() map[string]interface{} {
+	return copyDocument(provider.infra.Load().doc)
+}
+
+// Aliases in the infrastructure configuration, mapped to the ARN of the resource each one resolves to.
+// Aliases are meant for renaming resources without breaking application configurations, which should be updated.
+func (provider *Provider) Aliases() map[string]string {
+	aliases := make(map[string]string)
+	for alias, target := range provider.infra.Load().aliases {
+		aliases[alias] = target
+	}
+	return aliases
+}
+
+// loadEnvInfra loads the configuration of the environment on top of the configurations of the environments it extends.
+//
+// The configuration of an environment is made of the main file, `<env>.<ext>`, and the fragments in the `<env>.d`
+// folder next to it. The first of InfraConfigFolders with either of them is used. Fragments are loaded in lexical order
+// after the main file and every file can include others through the `$include` key.
+func (provider *Provider) loadEnvInfra(env string, snapshot *infra, stack []string) (map[string]interface{}, error) {
+	for _, child := range stack {
+		if child == env {
+			return nil, fmt.Errorf("environment inheritance cycle %s", strings.Join(append(stack, env), " -> "))
+		}
+	}
+
+	main, files, dirs, err := provider.findInfra(env)
+	if err != nil {
+		if len(stack) > 0 {
+			return nil, fmt.Errorf("environment %s extended by %s; %w", env, stack[len(stack)-1], err)
+		}
+		return nil, err
+	}
+	if len(stack) == 0 {
+		snapshot.path = main
+	}
+	snapshot.watched = append(snapshot.watched, dirs...)
+
+	loader := infraLoader{
+		provider: provider,
+		doc:      make(map[string]interface{}),
+		owners:   make(map[string]string),
+		loaded:   make(map[string]bool),
+	}
+	for _, path := range files {
+		if err := loader.load(path); err != nil {
+			return nil, err
+		}
+	}
+	snapshot.files = append(snapshot.files, loader.files...)
+	snapshot.watched = append(snapshot.watched, loader.files...)
+
+	parents, err := extendsOf(env, loader.doc[extendsKey])
+	if err != nil {
+		return nil, err
+	}
+	delete(loader.doc, extendsKey)
+	if len(stack) == 0 && len(parents) == 0 {
+		parents = provider.settings.EnvParents
+	}
+
+	doc := make(map[string]interface{})
+	for _, parent := range parents {
+		if snapshot.inChain(parent) {
+			continue
+		}
+		parentDoc, err := provider.loadEnvInfra(parent, snapshot, append(stack, env))
+		if err != nil {
+			return nil, err
+		}
+		doc = overlayInfra(doc, parentDoc, nil)
+	}
+	snapshot.chain = append(snapshot.chain, env)
+
+	return overlayInfra(doc, loader.doc, nil), nil
+}
+
+// findInfra returns the main file, the files and the folders making up the infrastructure configuration of env.
+func (provider *Provider) findInfra(env string) (main string, files []string, dirs []string, err error) {
+	for _, folder := range provider.settings.InfraConfigFolders {
+		if path, found := findConfigFile([]string{folder}, env); found {
+			main = path
+			files = append(files, path)
+		}
+		dir := filepath.Join(folder, env+".d")
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			fragments, err := listConfigFiles(dir)
+			if err != nil {
+				return "", nil, nil, err
+			}
+			files = append(files, fragments...)
+			dirs = append(dirs, dir)
+		}
+		if main != "" || len(dirs) > 0 {
+			if main == "" && len(files) > 0 {
+				main = files[0]
+			}
+			return main, files, dirs, nil
+		}
+	}
+	return "", nil, nil, fmt.Errorf("failed to read in infrastructure resource configuration; no configuration file %s found in %v", env, provider.settings.InfraConfigFolders)
+}
+
+// extendsOf returns the environments extended, as defined by the value of the `extends` key.
+func extendsOf(env string, value interface{}) ([]string, error) {
+	switch extends := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{extends}, nil
+	case []interface{}:
+		parents := make([]string, 0, len(extends))
+		for _, item := range extends {
+			parent, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid %s in environment %s; expected an environment name or list of names", extendsKey, env)
+			}
+			parents = append(parents, parent)
+		}
+		return parents, nil
+	default:
+		return nil, fmt.Errorf("invalid %s in environment %s; expected an environment name or list of names", extendsKey, env)
+	}
+}
+
+// overlayInfra returns a new document with the overlay on top of the base. Resources in the overlay replace the ones
+// with the same name in the base, as a whole, while every other object is merged key by key.
+func overlayInfra(base, overlay map[string]interface{}, path []string) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(overlay))
+	for key, value := range base {
+		merged[key] = value
+	}
+	collection := resources.IsCollection(path...)
+	for key, value := range overlay {
+		values, isMap := value.(map[string]interface{})
+		current, exists := merged[key].(map[string]interface{})
+		if !collection && isMap && exists {
+			merged[key] = overlayInfra(current, values, append(path[:len(path):len(path)], key))
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+// listConfigFiles in the folder with a supported extension, sorted by name.
+func listConfigFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read folder %s; %w", dir, err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !isConfigFile(entry.Name()) {
+			continue
+		}
+		files = append(files, filepath.Join(dir, entry.Name()))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func isConfigFile(path string) bool {
+	ext := strings.TrimPrefix(filepath.Ext(path), ".")
+	for _, supported := range configExtensions {
+		if ext == supported {
+			return true
+		}
+	}
+	return false
+}
+
+// infraLoader merges infrastructure configuration files into a single document while making sure each resource is
+// only defined once.
+type infraLoader struct {
+	provider *Provider
+	doc      map[string]interface{}
+	// owners maps each resource path to the file defining it.
+	owners map[string]string
+	loaded map[string]bool
+	files  []string
+}
+
+func (loader *infraLoader) load(path string) error {
+	if loader.loaded[path] {
+		return nil
+	}
+	loader.loaded[path] = true
+	loader.files = append(loader.files, path)
+
+	doc, err := loader.provider.readDocument(path)
+	if err != nil {
+		return err
+	}
+
+	includes, err := includesOf(path, doc[includeKey])
+	if err != nil {
+		return err
+	}
+	delete(doc, includeKey)
+
+	if err := loader.merge(loader.doc, doc, nil, path); err != nil {
+		return err
+	}
+	for _, include := range includes {
+		if err := loader.load(include); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// merge the fragment into the document, failing if a resource is already defined by another file.
+func (loader *infraLoader) merge(doc, fragment map[string]interface{}, path []string, file string) error {
+	collection := resources.IsCollection(path...)
+	for key, value := range fragment {
+		keyPath := append(path[:len(path):len(path)], key)
+		if collection {
+			id := strings.Join(keyPath, "/")
+			if owner, found := loader.owners[id]; found {
+				return fmt.Errorf("resource arn://%s is defined in both %s and %s", id, owner, file)
+			}
+			loader.owners[id] = file
+			doc[key] = value
+			continue
+		}
+		values, isMap := value.(map[string]interface{})
+		current, exists := doc[key].(map[string]interface{})
+		switch {
+		case isMap && exists:
+			if err := loader.merge(current, values, keyPath, file); err != nil {
+				return err
+			}
+		case isMap:
+			current = make(map[string]interface{})
+			doc[key] = current
+			if err := loader.merge(current, values, keyPath, file); err != nil {
+				return err
+			}
+		default:
+			doc[key] = value
+		}
+	}
+	return nil
+}
+
+// includesOf returns the paths of the files to include, resolved relative to the file including them.
+func includesOf(path string, value interface{}) ([]string, error) {
+	var patterns []string
+	switch include := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		patterns = append(patterns, include)
+	case []interface{}:
+		for _, item := range include {
+			pattern, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid %s in %s; expected a path or list of paths", includeKey, path)
+			}
+			patterns = append(patterns, pattern)
+		}
+	default:
+		return nil, fmt.Errorf("invalid %s in %s; expected a path or list of paths", includeKey, path)
+	}
+
+	var files []string
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(filepath.Dir(path), pattern)
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s pattern %s in %s; %w", includeKey, pattern, path, err)
+		}
+		if len(matches) == 0 && !strings.ContainsAny(pattern, "*?[") {
+			return nil, fmt.Errorf("file %s included by %s not found", pattern, path)
+		}
+		files = append(files, matches...)
+	}
+	return files, nil
+}
@@ -0,0 +1,233 @@
+package infrastructure
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProviderInfraFragments(t *testing.T) {
+	provider, err := NewProvider(ProviderSettings{
+		EnvName:       "fragments",
+		SystemName:    "sys",
+		ComponentName: "cmp",
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, "testdata/infra/fragments.json", provider.ResourcePath())
+	assert.Equal(t, []string{
+		"testdata/infra/fragments.json",
+		"testdata/infra/shared/webservices.json",
+		"testdata/infra/fragments.d/messaging-kafka.yaml",
+		"testdata/infra/fragments.d/storage.json",
+	}, provider.ResourcePaths())
+
+	locator := provider.Locator()
+	assert.NoError(t, locator.LocateRedisResource("arn://storage/redis/cache").Validate())
+	assert.NoError(t, locator.LocateWebserviceResource("arn://webservices/api").Validate())
+	assert.NoError(t, locator.LocateKafkaClusterResource("arn://messaging/kafka/clusters/c1").Validate())
+	pg := locator.LocatePostgresResource("arn://storage/postgres/users")
+	assert.NoError(t, pg.Validate())
+	assert.Equal(t, "cmp", pg.User)
+}
+
+func TestProviderInfraFragmentsOnly(t *testing.T) {
+	infraDir := t.TempDir()
+	assert.NoError(t, os.Mkdir(filepath.Join(infraDir, "only.d"), 0o755))
+	writeFile(t, filepath.Join(infraDir, "only.d", "b.json"), `{"storage": {"redis": {"b": {"address": "b:6379"}}}}`)
+	writeFile(t, filepath.Join(infraDir, "only.d", "a.json"), `{"storage": {"redis": {"a": {"address": "a:6379"}}}}`)
+	writeFile(t, filepath.Join(infraDir, "only.d", "notes.txt"), `not a configuration file`)
+
+	provider, err := NewProvider(ProviderSettings{
+		EnvName:            "only",
+		SystemName:         "sys",
+		ComponentName:      "cmp",
+		InfraConfigFolders: []string{infraDir},
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{
+		filepath.Join(infraDir, "only.d", "a.json"),
+		filepath.Join(infraDir, "only.d", "b.json"),
+	}, provider.ResourcePaths())
+	assert.Equal(t, "a:6379", provider.Locator().LocateRedisResource("arn://storage/redis/a").Address)
+	assert.Equal(t, "b:6379", provider.Locator().LocateRedisResource("arn://storage/redis/b").Address)
+}
+
+func TestProviderInfraFragmentErrors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		files  map[string]string
+		errMsg string
+	}{
+		{
+			name: "duplicate resource",
+			files: map[string]string{
+				"dup.json":      `{"storage": {"redis": {"cache": {"address": "a:6379"}}}}`,
+				"dup.d/a.json":  `{"storage": {"redis": {"other": {"address": "b:6379"}}}}`,
+				"dup.d/b.json":  `{"storage": {"redis": {"cache": {"address": "c:6379"}}}}`,
+				"dup.d/c.jsonx": `{"storage": {"redis": {"cache": {"address": "d:6379"}}}}`,
+			},
+			errMsg: "resource arn://storage/redis/cache is defined in both %[1]s/dup.json and %[1]s/dup.d/b.json",
+		},
+		{
+			name: "duplicate included resource",
+			files: map[string]string{
+				"dup.json":      `{"$include": ["shared/*.json"], "webservices": {"api": {"url": "https://a"}}}`,
+				"shared/a.json": `{"webservices": {"api": {"url": "https://b"}}}`,
+			},
+			errMsg: "resource arn://webservices/api is defined in both %[1]s/dup.json and %[1]s/shared/a.json",
+		},
+		{
+			name: "missing include",
+			files: map[string]string{
+				"dup.json": `{"$include": "missing.json"}`,
+			},
+			errMsg: "file %[1]s/missing.json included by %[1]s/dup.json not found",
+		},
+		{
+			name: "invalid include",
+			files: map[string]string{
+				"dup.json": `{"$include": 1}`,
+			},
+			errMsg: "invalid $include in %[1]s/dup.json",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			infraDir := t.TempDir()
+			for name, content := range tc.files {
+				assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(infraDir, name)), 0o755))
+				writeFile(t, filepath.Join(infraDir, name), content)
+			}
+			_, err := NewProvider(ProviderSettings{
+				EnvName:            "dup",
+				SystemName:         "sys",
+				ComponentName:      "cmp",
+				InfraConfigFolders: []string{infraDir},
+			})
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), fmt.Sprintf(tc.errMsg, infraDir))
+			}
+		})
+	}
+}
+
+func TestProviderInfraExtends(t *testing.T) {
+	infraDir := t.TempDir()
+	writeFile(t, filepath.Join(infraDir, "base.json"), `{
+		"storage": {
+			"redis": {
+				"cache": {"address": "base-cache:6379"},
+				"sessions": {"address": "base-sessions:6379", "db": 2}
+			}
+		},
+		"webservices": {"api": {"url": "https://base.example.com"}}
+	}`)
+	writeFile(t, filepath.Join(infraDir, "staging.json"), `{
+		"extends": "base",
+		"storage": {"redis": {"sessions": {"address": "staging-sessions:6379"}}}
+	}`)
+	writeFile(t, filepath.Join(infraDir, "canary.json"), `{
+		"extends": ["staging"],
+		"webservices": {"api": {"url": "https://canary.example.com"}}
+	}`)
+
+	provider, err := NewProvider(ProviderSettings{
+		EnvName:            "canary",
+		SystemName:         "sys",
+		ComponentName:      "cmp",
+		InfraConfigFolders: []string{infraDir},
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	assert.Equal(t, []string{"base", "staging", "canary"}, provider.EnvChain())
+	assert.Equal(t, filepath.Join(infraDir, "canary.json"), provider.ResourcePath())
+	locator := provider.Locator()
+	assert.Equal(t, "base-cache:6379", locator.LocateRedisResource("arn://storage/redis/cache").Address)
+	sessions := locator.LocateRedisResource("arn://storage/redis/sessions")
+	assert.Equal(t, "staging-sessions:6379", sessions.Address)
+	assert.Zero(t, sessions.DB, "resources of a child environment must replace the parent ones as a whole")
+	assert.Equal(t, "https://canary.example.com", locator.LocateWebserviceResource("arn://webservices/api").BaseURL)
+}
+
+func TestProviderInfraEnvParents(t *testing.T) {
+	infraDir := t.TempDir()
+	writeFile(t, filepath.Join(infraDir, "base.json"), `{"storage": {"redis": {"cache": {"address": "base:6379"}}}}`)
+	writeFile(t, filepath.Join(infraDir, "shared.json"), `{"storage": {"redis": {"cache": {"address": "shared:6379"}}}}`)
+	writeFile(t, filepath.Join(infraDir, "prod.json"), `{"webservices": {"api": {"url": "https://prod.example.com"}}}`)
+
+	provider, err := NewProvider(ProviderSettings{
+		EnvName:            "prod",
+		EnvParents:         []string{"base", "shared"},
+		SystemName:         "sys",
+		ComponentName:      "cmp",
+		InfraConfigFolders: []string{infraDir},
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, []string{"base", "shared", "prod"}, provider.EnvChain())
+	assert.Equal(t, "shared:6379", provider.Locator().LocateRedisResource("arn://storage/redis/cache").Address)
+}
+
+func TestProviderInfraExtendsErrors(t *testing.T) {
+	infraDir := t.TempDir()
+	writeFile(t, filepath.Join(infraDir, "a.json"), `{"extends": "b"}`)
+	writeFile(t, filepath.Join(infraDir, "b.json"), `{"extends": "c"}`)
+	writeFile(t, filepath.Join(infraDir, "c.json"), `{"extends": "a"}`)
+	writeFile(t, filepath.Join(infraDir, "orphan.json"), `{"extends": "missing"}`)
+	writeFile(t, filepath.Join(infraDir, "invalid.json"), `{"extends": 1}`)
+
+	testCases := map[string]string{
+		"a":       "environment inheritance cycle a -> b -> c -> a",
+		"orphan":  "environment missing extended by orphan",
+		"invalid": "invalid extends in environment invalid",
+	}
+	for env, expected := range testCases {
+		t.Run(env, func(t *testing.T) {
+			_, err := NewProvider(ProviderSettings{
+				EnvName:            env,
+				SystemName:         "sys",
+				ComponentName:      "cmp",
+				InfraConfigFolders: []string{infraDir},
+			})
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), expected)
+			}
+		})
+	}
+}
+
+func TestProviderStrictValidation(t *testing.T) {
+	infraDir := t.TempDir()
+	writeFile(t, filepath.Join(infraDir, "test.json"), `{
+		"storage": {"postgres": {"users": {"host": "pg", "user": "app"}}},
+		"webservices": {"api": {"url": "https://api"}}
+	}`)
+	settings := ProviderSettings{
+		EnvName:            "test",
+		SystemName:         "sys",
+		ComponentName:      "cmp",
+		InfraConfigFolders: []string{infraDir},
+	}
+
+	provider, err := NewProvider(settings)
+	if assert.NoError(t, err) {
+		assert.Error(t, provider.Locator().ValidateAll())
+	}
+
+	settings.StrictValidation = true
+	_, err = NewProvider(settings)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "arn://storage/postgres/users: postgres database configuration undefined")
+	}
+}
@@ -0,0 +1,148 @@
+// Package secrets provides built-in secret sources which can be registered with the infrastructure Provider and used
+// in configuration templates through the `secret` template function.
+//
+// The Directory and HTTP sources accept keys in the format `<path>[#<field>]`. When a field is given the secret is
+// expected to be a JSON object and only the value of that field is returned. The Files source reads the whole file, as
+// local paths may contain `#`.
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultDirectory is where Docker mounts secrets inside a container.
+const DefaultDirectory = "/run/secrets"
+
+// DefaultTimeout of requests of the HTTP source when it has no Client.
+const DefaultTimeout = 10 * time.Second
+
+var defaultClient = &http.Client{Timeout: DefaultTimeout}
+
+// Files reads secrets from local files.
+type Files struct {
+	// Root is prepended to relative paths. If empty, relative paths are relative to the working directory.
+	Root string
+}
+
+// Secret returns the contents of the file, without trailing line breaks. The key is the path of the file and has no
+// field.
+func (src Files) Secret(key string) (string, error) {
+	path := key
+	if src.Root != "" && !filepath.IsAbs(path) {
+		path = filepath.Join(src.Root, path)
+	}
+	return readFile(path, "")
+}
+
+// Directory reads secrets from a folder where each file is a secret, such as Docker secrets mounted in
+// /run/secrets or Kubernetes secrets mounted as a volume. Keys are file names inside the folder and can not
+// reference files outside of it.
+type Directory struct {
+	Path string
+}
+
+// NewDirectory creates a new Directory source. If path is empty then DefaultDirectory is used.
+func NewDirectory(path string) Directory {
+	if path == "" {
+		path = DefaultDirectory
+	}
+	return Directory{Path: path}
+}
+
+// Secret returns the contents of the file with the key's name, without trailing line breaks.
+func (src Directory) Secret(key string) (string, error) {
+	name, field := SplitKey(key)
+	path := filepath.Join(src.Path, filepath.Clean("/"+name))
+	return readFile(path, field)
+}
+
+// HTTP reads secrets from a key-value HTTP endpoint by issuing a GET request to `<URL>/<path>`.
+// The response body is the secret.
+type HTTP struct {
+	// URL is the base URL of the key-value store.
+	URL string
+	// Headers added to every request, such as authorization tokens.
+	Headers map[string]string
+	// Client used for requests. Defaults to a client with a timeout of DefaultTimeout.
+	Client *http.Client
+}
+
+// Secret fetches the secret from the endpoint.
+func (src HTTP) Secret(key string) (string, error) {
+	path, field := SplitKey(key)
+	endpoint, err := url.JoinPath(src.URL, path)
+	if err != nil {
+		return "", fmt.Errorf("invalid secret url for %s; %w", path, err)
+	}
+	req, err := http.NewRequest(http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create secret request for %s; %w", path, err)
+	}
+	for name, value := range src.Headers {
+		req.Header.Set(name, value)
+	}
+	client := src.Client
+	if client == nil {
+		client = defaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch secret %s; %w", path, err)
+	}
+	defer res.Body.Close()
+	payload, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret %s; %w", path, err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to fetch secret %s; unexpected status %d", path, res.StatusCode)
+	}
+	return Field(payload, field)
+}
+
+// SplitKey into the path and optional field of the secret.
+func SplitKey(key string) (path string, field string) {
+	if i := strings.LastIndex(key, "#"); i >= 0 {
+		return key[:i], key[i+1:]
+	}
+	return key, ""
+}
+
+// Field extracts the field from a JSON object. If field is empty the payload is returned without trailing line breaks.
+func Field(payload []byte, field string) (string, error) {
+	if field == "" {
+		return strings.TrimRight(string(payload), "\r\n"), nil
+	}
+	var object map[string]interface{}
+	if err := json.Unmarshal(payload, &object); err != nil {
+		return "", fmt.Errorf("failed to parse secret as JSON object; %w", err)
+	}
+	value, found := object[field]
+	if !found {
+		return "", fmt.Errorf("secret has no field %s", field)
+	}
+	if str, ok := value.(string); ok {
+		return str, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode secret field %s; %w", field, err)
+	}
+	return string(encoded), nil
+}
+
+func readFile(path string, field string) (string, error) {
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file; %w", err)
+	}
+	return Field(payload, field)
+}
@@ -0,0 +1,90 @@
+package secrets_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vredens/infrastructure/lib/secrets"
+)
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "pg"), []byte("s3cr3t\n"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "db#1.json"), []byte(`{"user": "app", "port": 5432}`), 0o600))
+
+	src := secrets.Files{Root: dir}
+	value, err := src.Secret("pg")
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t", value)
+
+	value, err = src.Secret(filepath.Join(dir, "db#1.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"user": "app", "port": 5432}`, value)
+
+	value, err = src.Secret("db#1.json")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"user": "app", "port": 5432}`, value)
+
+	_, err = src.Secret("pg#user")
+	assert.Error(t, err)
+
+	_, err = src.Secret("missing")
+	assert.Error(t, err)
+}
+
+func TestDirectory(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "pg"), []byte("s3cr3t\n"), 0o600))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "db"), []byte(`{"user": "app"}`), 0o600))
+
+	src := secrets.NewDirectory(dir)
+	value, err := src.Secret("pg")
+	assert.NoError(t, err)
+	assert.Equal(t, "s3cr3t", value)
+
+	value, err = src.Secret("db#user")
+	assert.NoError(t, err)
+	assert.Equal(t, "app", value)
+
+	_, err = src.Secret("../" + filepath.Base(dir) + "/pg")
+	assert.Error(t, err)
+
+	assert.Equal(t, secrets.DefaultDirectory, secrets.NewDirectory("").Path)
+}
+
+func TestHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/db/users":
+			_, _ = w.Write([]byte(`{"password": "pass"}`))
+		case "/v1/plain":
+			_, _ = w.Write([]byte("value\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	src := secrets.HTTP{URL: server.URL + "/v1", Headers: map[string]string{"X-Token": "token"}}
+	value, err := src.Secret("db/users#password")
+	assert.NoError(t, err)
+	assert.Equal(t, "pass", value)
+
+	value, err = src.Secret("plain")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+
+	_, err = src.Secret("missing")
+	assert.Error(t, err)
+
+	_, err = secrets.HTTP{URL: server.URL + "/v1"}.Secret("plain")
+	assert.Error(t, err)
+}
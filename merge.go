@@ -0,0 +1,156 @@
+package infrastructure
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/viper"
+)
+
+// ConfigMerge defines how LoadConfig combines the base application configuration file (e.g. `myapp.json`) with the
+// environment specific one (e.g. `myapp.dev.json`).
+type ConfigMerge int
+
+const (
+	// MergeOverlay unmarshals the base file and then the environment file into the same structure.
+	// Scalar values are replaced but objects and arrays are replaced or partially overwritten depending on the
+	// structure being unmarshalled into. This is the default.
+	MergeOverlay ConfigMerge = iota
+	// MergeDeep merges both files before unmarshalling. Objects are merged key by key, arrays are merged according to
+	// ProviderSettings.ArrayMerge and an explicit `null` in the environment file deletes the key from the base file.
+	MergeDeep
+)
+
+// ArrayMerge defines how arrays are merged when using MergeDeep.
+type ArrayMerge int
+
+const (
+	// ArrayReplace replaces arrays of the base file with the ones in the environment file. This is the default.
+	ArrayReplace ArrayMerge = iota
+	// ArrayAppend appends the items of arrays in the environment file to the ones in the base file.
+	ArrayAppend
+)
+
+// EffectiveConfig returns the application configuration document for the namespace resulting from the deep merge of
+// the base and environment specific configuration files. It is meant for debugging configurations and is available
+// regardless of the ProviderSettings.ConfigMerge used.
+func (provider *Provider) EffectiveConfig(namespace string) (map[string]interface{}, error) {
+	global, err := provider.loadDocument(namespace)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load global configuration file; %w", err)
+	}
+
+	env, err := provider.loadDocument(namespace + "." + provider.settings.EnvName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load environment configuration file; %w", err)
+	}
+
+	if global == nil && env == nil {
+		return nil, fmt.Errorf("no configuration found")
+	}
+	return mergeDocuments(global, env, provider.settings.ArrayMerge), nil
+}
+
+// loadDocument finds, renders and parses an application configuration file.
+// Returns a nil document if no file is found.
+func (provider *Provider) loadDocument(name string) (map[string]interface{}, error) {
+	path, found := findConfigFile(provider.settings.AppConfigFolders, name)
+	if !found {
+		return nil, nil
+	}
+	doc, err := provider.readDocument(path)
+	if err != nil {
+		return nil, err
+	}
+	provider.trackAppFile(path)
+	return doc, nil
+}
+
+// readDocument renders the file and parses it into a generic document.
+func (provider *Provider) readDocument(path string) (map[string]interface{}, error) {
+	fileContent, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s", path)
+	}
+	renderedConfig, err := provider.RenderSecrets(string(fileContent))
+	if err != nil {
+		return nil, fmt.Errorf("failed to render secrets; %w", err)
+	}
+	doc, err := parseDocument(formatOf(path), []byte(renderedConfig))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rendered configuration %s; %w", path, err)
+	}
+	return doc, nil
+}
+
+// decodeDocument into the config structure using the same decoding rules as the rest of the Provider. The document goes
+// through viper, which lower cases its keys, so keys match the json tags of the structure regardless of their case.
+func decodeDocument(doc map[string]interface{}, config interface{}) error {
+	loader := viper.New()
+	if err := loader.MergeConfigMap(doc); err != nil {
+		return fmt.Errorf("failed to read configuration document; %w", err)
+	}
+	if err := loader.Unmarshal(config, jsonDecoding); err != nil {
+		return fmt.Errorf("fail to unmarshal json configuration into the provided structure; %w", err)
+	}
+	return nil
+}
+
+// mergeDocuments returns a new document with the overlay deep merged into the base.
+// Neither of the provided documents is modified.
+func mergeDocuments(base, overlay map[string]interface{}, arrays ArrayMerge) map[string]interface{} {
+	merged := make(map[string]interface{}, len(base)+len(overlay))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range overlay {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = mergeValues(merged[key], value, arrays)
+	}
+	return merged
+}
+
+func mergeValues(base, overlay interface{}, arrays ArrayMerge) interface{} {
+	switch overlayValue := overlay.(type) {
+	case map[string]interface{}:
+		if baseValue, ok := base.(map[string]interface{}); ok {
+			return mergeDocuments(baseValue, overlayValue, arrays)
+		}
+		return mergeDocuments(nil, overlayValue, arrays)
+	case []interface{}:
+		if baseValue, ok := base.([]interface{}); ok && arrays == ArrayAppend {
+			items := make([]interface{}, 0, len(baseValue)+len(overlayValue))
+			return append(append(items, baseValue...), overlayValue...)
+		}
+		return overlayValue
+	default:
+		return overlay
+	}
+}
+
+// copyDocument returns a deep copy of the document.
+func copyDocument(doc map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(doc))
+	for key, value := range doc {
+		copied[key] = copyValue(value)
+	}
+	return copied
+}
+
+func copyValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return copyDocument(v)
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = copyValue(item)
+		}
+		return items
+	default:
+		return value
+	}
+}
@@ -0,0 +1,251 @@
+package infrastructure
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// overrideSeparator separates the prefix and each segment of the path in the name of an override environment variable.
+const overrideSeparator = "__"
+
+// EnvOverride is a configuration value replaced by an environment variable.
+// The value itself is not kept since it is often a secret.
+type EnvOverride struct {
+	// Variable is the name of the environment variable, e.g. `INFRA__STORAGE__POSTGRES__USERS__HOST`.
+	Variable string
+	// Namespace of the application configuration overridden. Empty for the infrastructure configuration.
+	Namespace string
+	// Path of the value overridden, e.g. `storage.postgres.users.host`.
+	Path string
+}
+
+// EnvOverrides applied to the current infrastructure configuration and to application configurations loaded through
+// LoadConfig, in that order.
+func (provider *Provider) EnvOverrides() []EnvOverride {
+	overrides := append([]EnvOverride{}, provider.infra.Load().overrides...)
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	namespaces := make([]string, 0, len(provider.appOverrides))
+	for namespace := range provider.appOverrides {
+		namespaces = append(namespaces, namespace)
+	}
+	sort.Strings(namespaces)
+	for _, namespace := range namespaces {
+		overrides = append(overrides, provider.appOverrides[namespace]...)
+	}
+	return overrides
+}
+
+// applyEnvOverrides replaces values in the config with the ones of environment variables named after them.
+//
+// Overrides are enabled by ProviderSettings.EnvVarPrefix. Variables are named `<prefix>__<path>` for the
+// infrastructure configuration and `<prefix>__<namespace>__<path>` for application configurations, where path
+// segments are separated by a double underscore. Segments match field names and map keys ignoring case, with any `-`
+// or `.` in them replaced by `_`. Only existing values are overridden and variables matching nothing are ignored.
+func (provider *Provider) applyEnvOverrides(namespace string, config interface{}) ([]EnvOverride, error) {
+	prefix := strings.TrimRight(provider.settings.EnvVarPrefix, "_")
+	if prefix == "" {
+		return nil, nil
+	}
+	prefix += overrideSeparator
+	if namespace != "" {
+		prefix += normalizeSegment(namespace) + overrideSeparator
+	}
+
+	variables := make([]string, 0)
+	for variable := range provider.data.Env {
+		if strings.HasPrefix(variable, prefix) && len(variable) > len(prefix) {
+			variables = append(variables, variable)
+		}
+	}
+	sort.Strings(variables)
+
+	target := reflect.ValueOf(config)
+	if target.Kind() != reflect.Pointer || target.IsNil() {
+		return nil, fmt.Errorf("failed to apply environment overrides; config must be a non nil pointer")
+	}
+
+	var overrides []EnvOverride
+	for _, variable := range variables {
+		segments := strings.Split(strings.TrimPrefix(variable, prefix), overrideSeparator)
+		path, err := override(target.Elem(), segments, provider.data.Env[variable])
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply environment override %s; %w", variable, err)
+		}
+		if path == nil {
+			continue
+		}
+		overrides = append(overrides, EnvOverride{
+			Variable:  variable,
+			Namespace: namespace,
+			Path:      strings.Join(path, "."),
+		})
+	}
+	return overrides, nil
+}
+
+// override the value at the path of segments, returning the path using the actual names of fields and keys.
+// Returns a nil path if nothing matches the segments.
+func override(value reflect.Value, segments []string, raw string) ([]string, error) {
+	if len(segments) == 0 {
+		if err := setFromString(value, raw); err != nil {
+			return nil, err
+		}
+		return []string{}, nil
+	}
+
+	switch value.Kind() {
+	case reflect.Pointer:
+		if value.IsNil() {
+			return nil, nil
+		}
+		return override(value.Elem(), segments, raw)
+	case reflect.Interface:
+		if value.IsNil() {
+			return nil, nil
+		}
+		// values held by interfaces are not addressable so they are copied, overridden and put back.
+		copied := reflect.New(value.Elem().Type()).Elem()
+		copied.Set(value.Elem())
+		path, err := override(copied, segments, raw)
+		if path != nil && err == nil {
+			value.Set(copied)
+		}
+		return path, err
+	case reflect.Struct:
+		return overrideField(value, segments, raw)
+	case reflect.Map:
+		if value.Type().Key().Kind() != reflect.String {
+			return nil, nil
+		}
+		iter := value.MapRange()
+		for iter.Next() {
+			key := iter.Key()
+			if normalizeSegment(key.String()) != normalizeSegment(segments[0]) {
+				continue
+			}
+			// map values are not addressable so they are copied, overridden and put back.
+			copied := reflect.New(value.Type().Elem()).Elem()
+			copied.Set(iter.Value())
+			path, err := override(copied, segments[1:], raw)
+			if path == nil || err != nil {
+				return path, err
+			}
+			value.SetMapIndex(key, copied)
+			return append([]string{key.String()}, path...), nil
+		}
+	}
+	return nil, nil
+}
+
+// overrideField of the struct matching the first segment. Fields of embedded structs, and keys of the map holding the
+// remaining values of the configuration, are matched as if they were fields of the struct itself.
+func overrideField(value reflect.Value, segments []string, raw string) ([]string, error) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" && (options == "remain" || field.Anonymous && field.Type.Kind() == reflect.Struct) {
+			path, err := override(value.Field(i), segments, raw)
+			if path != nil || err != nil {
+				return path, err
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if normalizeSegment(name) != normalizeSegment(segments[0]) {
+			continue
+		}
+		path, err := override(value.Field(i), segments[1:], raw)
+		if path == nil || err != nil {
+			return path, err
+		}
+		return append([]string{name}, path...), nil
+	}
+	return nil, nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// setFromString converts the raw value into the type of value. Slices are comma separated lists.
+func setFromString(value reflect.Value, raw string) error {
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Bool:
+		parsed, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid bool %q", raw)
+		}
+		value.SetBool(parsed)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value.Type() == durationType {
+			parsed, err := time.ParseDuration(raw)
+			if err != nil {
+				return fmt.Errorf("invalid duration %q", raw)
+			}
+			value.SetInt(int64(parsed))
+			return nil
+		}
+		parsed, err := strconv.ParseInt(raw, 10, value.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid %s %q", value.Type(), raw)
+		}
+		value.SetInt(parsed)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		parsed, err := strconv.ParseUint(raw, 10, value.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid %s %q", value.Type(), raw)
+		}
+		value.SetUint(parsed)
+	case reflect.Float32, reflect.Float64:
+		parsed, err := strconv.ParseFloat(raw, value.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("invalid %s %q", value.Type(), raw)
+		}
+		value.SetFloat(parsed)
+	case reflect.Slice:
+		var items []string
+		if strings.TrimSpace(raw) != "" {
+			items = strings.Split(raw, ",")
+		}
+		slice := reflect.MakeSlice(value.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := setFromString(slice.Index(i), strings.TrimSpace(item)); err != nil {
+				return err
+			}
+		}
+		value.Set(slice)
+	case reflect.Pointer:
+		elem := reflect.New(value.Type().Elem())
+		if err := setFromString(elem.Elem(), raw); err != nil {
+			return err
+		}
+		value.Set(elem)
+	case reflect.Interface:
+		if value.NumMethod() > 0 {
+			return fmt.Errorf("unsupported type %s", value.Type())
+		}
+		value.Set(reflect.ValueOf(raw))
+	default:
+		return fmt.Errorf("unsupported type %s", value.Type())
+	}
+	return nil
+}
+
+// normalizeSegment of an override path for comparison.
+func normalizeSegment(segment string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(segment))
+}
@@ -0,0 +1,105 @@
+package infrastructure
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProviderEnvOverrides(t *testing.T) {
+	infraDir := t.TempDir()
+	appDir := t.TempDir()
+	writeFile(t, filepath.Join(infraDir, "test.json"), `{
+		"storage": {
+			"postgres": {"users-db": {"host": "pg.local", "port": 5432, "database": "users", "user": "app"}},
+			"redis": {"cache": {"address": "redis.local:6379", "sentinels": ["a:26379"]}}
+		}
+	}`)
+	writeFile(t, filepath.Join(appDir, "my-app.json"), `{"workers": 1, "debug": false, "hosts": ["a"], "timeout": "1s", "db": {"arn": "arn://storage/postgres/users-db"}}`)
+
+	t.Setenv("OVR__STORAGE__POSTGRES__USERS_DB__HOST", "10.0.0.5")
+	t.Setenv("OVR__STORAGE__POSTGRES__USERS_DB__PORT", "6432")
+	t.Setenv("OVR__STORAGE__REDIS__CACHE__SENTINELS", "b:26379, c:26379")
+	t.Setenv("OVR__STORAGE__REDIS__UNKNOWN__ADDRESS", "ignored")
+	t.Setenv("OVR__MY_APP__WORKERS", "8")
+	t.Setenv("OVR__MY_APP__DEBUG", "true")
+	t.Setenv("OVR__MY_APP__HOSTS", "x,y")
+	t.Setenv("OVR__MY_APP__TIMEOUT", "5s")
+
+	provider, err := NewProvider(ProviderSettings{
+		EnvName:            "test",
+		SystemName:         "sys",
+		ComponentName:      "cmp",
+		EnvVarPrefix:       "OVR",
+		InfraConfigFolders: []string{infraDir},
+		AppConfigFolders:   []string{appDir},
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	pg := provider.Locator().LocatePostgresResource("arn://storage/postgres/users-db")
+	assert.NoError(t, pg.Validate())
+	assert.Equal(t, "10.0.0.5", pg.Host)
+	assert.Equal(t, uint16(6432), pg.Port)
+	assert.Equal(t, "users", pg.Database)
+	assert.Equal(t, []string{"b:26379", "c:26379"}, provider.Locator().LocateRedisResource("arn://storage/redis/cache").SentinelAddresses)
+
+	var config struct {
+		Workers int           `json:"workers"`
+		Debug   bool          `json:"debug"`
+		Hosts   []string      `json:"hosts"`
+		Timeout time.Duration `json:"timeout"`
+		DB      struct {
+			ARN string `json:"arn"`
+		} `json:"db"`
+	}
+	if !assert.NoError(t, provider.LoadConfig("my-app", &config)) {
+		t.FailNow()
+	}
+	assert.Equal(t, 8, config.Workers)
+	assert.True(t, config.Debug)
+	assert.Equal(t, []string{"x", "y"}, config.Hosts)
+	assert.Equal(t, 5*time.Second, config.Timeout)
+	assert.Equal(t, "arn://storage/postgres/users-db", config.DB.ARN)
+
+	assert.Equal(t, []EnvOverride{
+		{Variable: "OVR__STORAGE__POSTGRES__USERS_DB__HOST", Path: "storage.postgres.users-db.host"},
+		{Variable: "OVR__STORAGE__POSTGRES__USERS_DB__PORT", Path: "storage.postgres.users-db.port"},
+		{Variable: "OVR__STORAGE__REDIS__CACHE__SENTINELS", Path: "storage.redis.cache.sentinels"},
+		{Variable: "OVR__MY_APP__DEBUG", Namespace: "my-app", Path: "debug"},
+		{Variable: "OVR__MY_APP__HOSTS", Namespace: "my-app", Path: "hosts"},
+		{Variable: "OVR__MY_APP__TIMEOUT", Namespace: "my-app", Path: "timeout"},
+		{Variable: "OVR__MY_APP__WORKERS", Namespace: "my-app", Path: "workers"},
+	}, provider.EnvOverrides())
+}
+
+func TestProviderEnvOverridesInvalidValue(t *testing.T) {
+	infraDir := t.TempDir()
+	writeFile(t, filepath.Join(infraDir, "test.json"), `{"storage": {"redis": {"cache": {"address": "redis.local:6379", "db": 1}}}}`)
+	t.Setenv("BAD__STORAGE__REDIS__CACHE__DB", "one")
+
+	_, err := NewProvider(ProviderSettings{
+		EnvName:            "test",
+		SystemName:         "sys",
+		ComponentName:      "cmp",
+		EnvVarPrefix:       "BAD",
+		InfraConfigFolders: []string{infraDir},
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "failed to apply environment override BAD__STORAGE__REDIS__CACHE__DB")
+	}
+
+	provider, err := NewProvider(ProviderSettings{
+		EnvName:            "test",
+		SystemName:         "sys",
+		ComponentName:      "cmp",
+		InfraConfigFolders: []string{infraDir},
+	})
+	if assert.NoError(t, err) {
+		assert.Empty(t, provider.EnvOverrides())
+		assert.Equal(t, 1, provider.Locator().LocateRedisResource("arn://storage/redis/cache").DB)
+	}
+}
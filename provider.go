@@ -1,16 +1,17 @@
 package infrastructure
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
+	"log"
 	"os"
 	"strings"
-	"text/template"
+	"sync"
+	"sync/atomic"
 
-	"github.com/mitchellh/mapstructure"
-	"github.com/spf13/viper"
+	"github.com/vredens/infrastructure/lib/aws"
 	"github.com/vredens/infrastructure/lib/certs"
+	"github.com/vredens/infrastructure/lib/secrets"
 	"github.com/vredens/infrastructure/resources"
 )
 
@@ -39,6 +40,9 @@ var defaults ProviderSettings = ProviderSettings{
 type ProviderSettings struct {
 	// EnvName is used to select configuration files. Defaults to `local`.
 	EnvName string
+	// EnvParents are the environments whose infrastructure configuration is loaded, in order, before the one of EnvName
+	// which is loaded on top of them. Only used if the configuration of EnvName has no `extends` key.
+	EnvParents []string
 	// SystemName is the name by which your system is known. Defaults to `NOSYSTEM`.
 	SystemName string
 	// ComponentName is the name by which the running process is known, which can be part of a larger system.
@@ -46,6 +50,8 @@ type ProviderSettings struct {
 	ComponentName string
 	// EnvVarPrefix is the prefix used to look for environment variables.
 	// If empty then all environment variables are used.
+	// If set, it also enables overriding configuration values with environment variables such as
+	// `<prefix>__STORAGE__POSTGRES__USERS__HOST`, check provider.EnvOverrides().
 	EnvVarPrefix string
 	// CertFolders are locations where to look for certificate files (*.pem, etc).
 	// These folders are used by provider.Certs().
@@ -59,6 +65,30 @@ type ProviderSettings struct {
 	// These locations are used when initializing a new Provider.
 	// Defaults to /etc/infra, etc/infra, testdata/infra.
 	InfraConfigFolders []string
+	// ConfigMerge selects how provider.LoadConfig() combines the base and environment application configuration files.
+	// Defaults to MergeOverlay.
+	ConfigMerge ConfigMerge
+	// ArrayMerge selects how arrays are merged when ConfigMerge is MergeDeep. Defaults to ArrayReplace.
+	ArrayMerge ArrayMerge
+	// SecretSources available to configuration templates by name, e.g. `{{ secret "vault" "db/users#password" }}`.
+	// A source for local files is always registered as "file" unless one is provided with that name.
+	SecretSources map[string]SecretSource
+	// StrictTemplates makes rendering configuration templates fail on any reference which can not be resolved,
+	// such as a missing environment variable in {{ .Env.MISSING }}, instead of rendering it as an empty value.
+	// Use {{ env "NAME" | default "value" }} for optional environment variables.
+	StrictTemplates bool
+	// StrictValidation makes NewProvider, and reloading the configuration, fail if any resource in the infrastructure
+	// configuration is invalid. By default resources are only validated when located.
+	StrictValidation bool
+	// AvailabilityZone the process runs in, used to select the variants of resources in the same zone.
+	// Defaults to the zone found by lib/aws, if any.
+	AvailabilityZone string
+	// ZoneFallback is the order of the zones whose variants of resources are selected when a resource has no variant
+	// in the AvailabilityZone. Resources without a variant in any of the zones are used as they are.
+	ZoneFallback []string
+	// OnDeprecatedARN is called whenever a resource is located with the ARN of an alias, along with the ARN of the
+	// resource it is an alias of. Defaults to logging it.
+	OnDeprecatedARN func(arn, replacement string)
 }
 
 func (settings ProviderSettings) sanitize() ProviderSettings {
@@ -80,6 +110,20 @@ func (settings ProviderSettings) sanitize() ProviderSettings {
 	if settings.ComponentName == "" {
 		settings.ComponentName = defaults.ComponentName
 	}
+	if settings.AvailabilityZone == "" {
+		settings.AvailabilityZone = aws.New().AvailabilityZone()
+	}
+	if settings.OnDeprecatedARN == nil {
+		settings.OnDeprecatedARN = func(arn, replacement string) {
+			log.Printf("infrastructure: %s is a deprecated alias of %s", arn, replacement)
+		}
+	}
+	sources := make(map[string]SecretSource, len(settings.SecretSources)+1)
+	sources[FileSecretSource] = secrets.Files{}
+	for name, source := range settings.SecretSources {
+		sources[name] = source
+	}
+	settings.SecretSources = sources
 	return settings
 }
 
@@ -98,12 +142,20 @@ func (settings ProviderSettings) Validate() error {
 
 // Provider of infrastructure resources and repo of application settings.
 type Provider struct {
-	infraConfig  resources.Locator
-	cfgLoader    *viper.Viper
-	settings     ProviderSettings
-	resourcePath string
-	certs        certs.Certs
-	data         tmplData
+	infra    atomic.Pointer[infra]
+	settings ProviderSettings
+	certs    certs.Certs
+	data     tmplData
+
+	// reloadMu serializes reloads of the infrastructure configuration.
+	reloadMu sync.Mutex
+	// mu guards the fields used for watching configuration changes.
+	mu           sync.Mutex
+	callbacks    []func(err error)
+	watcher      *watcher
+	appFiles     map[string]string
+	infraSums    map[string]string
+	appOverrides map[string][]EnvOverride
 }
 
 type tmplData struct {
@@ -116,7 +168,10 @@ type tmplData struct {
 // NewProvider creates a new infrastructure provider with the given settings.
 func NewProvider(settings ProviderSettings) (*Provider, error) {
 	provider := &Provider{
-		settings: settings.sanitize(),
+		settings:     settings.sanitize(),
+		appFiles:     make(map[string]string),
+		infraSums:    make(map[string]string),
+		appOverrides: make(map[string][]EnvOverride),
 	}
 	if err := provider.settings.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid environment settings; %w", err)
@@ -135,97 +190,17 @@ func NewProvider(settings ProviderSettings) (*Provider, error) {
 		}
 	}
 
-	provider.cfgLoader = viper.New()
-	provider.cfgLoader.SetConfigType("json")
-	for _, path := range provider.settings.AppConfigFolders {
-		provider.cfgLoader.AddConfigPath(path)
-	}
-
-	vInfra, err := provider.loadInfraConfig()
+	snapshot, err := provider.loadInfra()
 	if err != nil {
-		return nil, fmt.Errorf("failed to create infra; %w", err)
-	}
-	provider.resourcePath = vInfra.ConfigFileUsed()
-	provider.infraConfig.SetProvider(provider)
-	if err := vInfra.Unmarshal(&provider.infraConfig, func(cfg *mapstructure.DecoderConfig) { cfg.TagName = "json" }); err != nil {
-		return provider, fmt.Errorf("failed to unmarshal infrastructure configuration; %w", err)
+		return nil, err
 	}
+	provider.infra.Store(snapshot)
 
 	provider.certs = certs.New(certs.Config{Locations: provider.settings.CertFolders})
 
 	return provider, nil
 }
 
-func (provider *Provider) loadInfraConfig() (empty *viper.Viper, err error) {
-	vInfra := viper.New()
-	vInfra.SetConfigName(provider.settings.EnvName)
-	vInfra.SetConfigType("json")
-	for _, path := range provider.settings.InfraConfigFolders {
-		vInfra.AddConfigPath(path)
-	}
-	if err := vInfra.ReadInConfig(); err != nil {
-		return nil, fmt.Errorf("failed to read in infrastructure resource configuration; %w", err)
-	}
-
-	fileContent, err := os.ReadFile(vInfra.ConfigFileUsed())
-	if err != nil {
-		return empty, fmt.Errorf("failed to read config file %s", vInfra.ConfigFileUsed())
-	}
-	renderedConfig, err := provider.RenderSecrets(string(fileContent))
-	if err != nil {
-		return empty, fmt.Errorf("failed to render secrets; %w", err)
-	}
-
-	err = vInfra.ReadConfig(strings.NewReader(renderedConfig))
-	if err != nil {
-		return empty, fmt.Errorf("failed to read config from rendered config; %w", err)
-	}
-
-	return vInfra, nil
-}
-
-// RenderSecrets using provider replaceVariables in the given config file
-// it will return an error when the config file has invalid placeholerds, such as
-// nonexisting functions (e.g. {{ test }}) or invalid properties (e.g. {{ .test }})
-func (provider Provider) RenderSecrets(value string) (empty string, err error) {
-	t, err := template.New("secrets").Parse(value)
-	if err != nil {
-		return empty, fmt.Errorf("failed create template from config file; %w", err)
-	}
-
-	tmp, err := renderTemplate(t, provider.data)
-	if err != nil {
-		return empty, fmt.Errorf("failed render template; %w", err)
-	}
-	return string(tmp), nil
-}
-
-// RenderSecrets using provider replaceVariables in the given config file returning
-// given value if doesn't exist or is an invalid template function
-func (provider Provider) RenderSecret(value string) string {
-	t, err := template.New("secrets").Parse(value)
-	if err != nil {
-		return value
-	}
-
-	tmp, err := renderTemplate(t, provider.data)
-	if err != nil {
-		return value
-	}
-	return string(tmp)
-}
-
-func renderTemplate(tmpl *template.Template, data interface{}) ([]byte, error) {
-	// TODO: this should be a buffer pool and 2048 is based on the amazing science of wild guess.
-	buf := bytes.NewBuffer(make([]byte, 0, 2048))
-
-	if err := tmpl.Execute(buf, data); err != nil {
-		return nil, fmt.Errorf("failed to execute template; %w", err)
-	}
-
-	return buf.Bytes(), nil
-}
-
 // LoadConfigFromTemplate into the config structure provided.
 func (provider *Provider) LoadConfigFromTemplate(template []byte, config interface{}) error {
 	renderedConfig, err := provider.RenderSecrets(string(template))
@@ -233,24 +208,37 @@ func (provider *Provider) LoadConfigFromTemplate(template []byte, config interfa
 		return fmt.Errorf("failed to render secrets; %w", err)
 	}
 
-	err = provider.cfgLoader.ReadConfig(strings.NewReader(renderedConfig))
-	if err != nil {
-		return fmt.Errorf("failed to read rendered configuration")
+	return unmarshalConfig("json", renderedConfig, config)
+}
+
+// LoadConfig into the config structure provided.
+// The base configuration file for the namespace is loaded first and then the environment specific one, if any,
+// which are combined according to ProviderSettings.ConfigMerge.
+// Environment variable overrides for the namespace are applied last, check ProviderSettings.EnvVarPrefix.
+func (provider *Provider) LoadConfig(namespace string, config interface{}) error {
+	if err := provider.loadConfigFiles(namespace, config); err != nil {
+		return err
 	}
 
-	if err := provider.cfgLoader.Unmarshal(config, func(cfg *mapstructure.DecoderConfig) { cfg.TagName = "json" }); err != nil {
-		return fmt.Errorf("fail to unmarshal json configuration into the provided structure; %w", err)
+	overrides, err := provider.applyEnvOverrides(namespace, config)
+	if err != nil {
+		return err
 	}
+	provider.mu.Lock()
+	provider.appOverrides[namespace] = overrides
+	provider.mu.Unlock()
 	return nil
 }
 
-func isConfigNotFound(err error) bool {
-	var expected viper.ConfigFileNotFoundError
-	return errors.As(err, &expected)
-}
+func (provider *Provider) loadConfigFiles(namespace string, config interface{}) error {
+	if provider.settings.ConfigMerge == MergeDeep {
+		doc, err := provider.EffectiveConfig(namespace)
+		if err != nil {
+			return err
+		}
+		return decodeDocument(doc, config)
+	}
 
-// LoadConfig into the config structure provided.
-func (provider *Provider) LoadConfig(namespace string, config interface{}) error {
 	global, err := provider.loadConfig(namespace, config)
 	if err != nil {
 		return fmt.Errorf("failed to load global configuration file; %w", err)
@@ -268,16 +256,14 @@ func (provider *Provider) LoadConfig(namespace string, config interface{}) error
 }
 
 func (provider *Provider) loadConfig(name string, config interface{}) (loaded bool, err error) {
-	provider.cfgLoader.SetConfigName(name)
-	if err := provider.cfgLoader.ReadInConfig(); err != nil {
-		if isConfigNotFound(err) {
-			return false, nil
-		}
-		return false, fmt.Errorf("read failed; %w", err)
+	path, found := findConfigFile(provider.settings.AppConfigFolders, name)
+	if !found {
+		return false, nil
 	}
-	if err := provider.LoadConfigFromFile(provider.cfgLoader.ConfigFileUsed(), config); err != nil {
+	if err := provider.LoadConfigFromFile(path, config); err != nil {
 		return false, fmt.Errorf("load failed; %w", err)
 	}
+	provider.trackAppFile(path)
 	return true, nil
 }
 
@@ -292,29 +278,50 @@ func (provider *Provider) LoadConfigFromFile(path string, config interface{}) er
 		return fmt.Errorf("failed to render secrets; %w", err)
 	}
 
-	err = provider.cfgLoader.ReadConfig(strings.NewReader(renderedConfig))
-	if err != nil {
-		return fmt.Errorf("failed to read rendered configuration")
-	}
+	return unmarshalConfig(formatOf(path), renderedConfig, config)
+}
 
-	if err := provider.cfgLoader.Unmarshal(config, func(cfg *mapstructure.DecoderConfig) { cfg.TagName = "json" }); err != nil {
-		return fmt.Errorf("fail to unmarshal json configuration into the provided structure; %w", err)
+// unmarshalConfig in the given format into the config structure provided.
+func unmarshalConfig(format string, content string, config interface{}) error {
+	doc, err := parseDocument(format, []byte(content))
+	if err != nil {
+		return fmt.Errorf("failed to read rendered configuration; %w", err)
 	}
-	return nil
+	return decodeDocument(doc, config)
 }
 
-func (provider Provider) Certs() certs.Certs {
+func (provider *Provider) Certs() certs.Certs {
 	return provider.certs
 }
 
-// ResourcePath of the current configuration
+// ResourcePath of the current configuration.
+// This is the main infrastructure configuration file, check ResourcePaths for every file used.
 func (provider *Provider) ResourcePath() string {
-	return provider.resourcePath
+	return provider.infra.Load().path
+}
+
+// ResourcePaths of every file contributing to the current configuration, in the order they were loaded.
+func (provider *Provider) ResourcePaths() []string {
+	files := provider.infra.Load().files
+	paths := make([]string, len(files))
+	copy(paths, files)
+	return paths
+}
+
+// EnvChain of environments whose infrastructure configuration was loaded, starting with the furthest ancestor
+// and ending with the current environment.
+func (provider *Provider) EnvChain() []string {
+	chain := provider.infra.Load().chain
+	envs := make([]string, len(chain))
+	copy(envs, chain)
+	return envs
 }
 
 // Locator gives access to the infrastructure configuration for implementing your own providers.
+// The returned Locator is replaced, not modified, when the configuration is reloaded so callers
+// should fetch it again after being notified of a change.
 func (provider *Provider) Locator() *resources.Locator {
-	return &provider.infraConfig
+	return &provider.infra.Load().locator
 }
 
 // SystemName your process is running in.
@@ -327,12 +334,17 @@ func (provider *Provider) ComponentName() string {
 	return provider.settings.ComponentName
 }
 
-// Environment your process is running in.
-func (provider *Provider) Environment() string {
-	return provider.settings.EnvName
+// AvailabilityZone your process is running in, if known.
+func (provider *Provider) AvailabilityZone() string {
+	return provider.settings.AvailabilityZone
 }
 
-// RegisterCallback for notification when a configuration changes.
-func (provider *Provider) RegisterCallback(fn func()) {
+// DeprecatedARN is called by the Locator when a resource is located with the ARN of an alias.
+func (provider *Provider) DeprecatedARN(arn, replacement string) {
+	provider.settings.OnDeprecatedARN(arn, replacement)
+}
 
+// Environment your process is running in.
+func (provider *Provider) Environment() string {
+	return provider.settings.EnvName
 }
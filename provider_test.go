@@ -393,9 +393,199 @@ func TestProviderLoadConfig(t *testing.T) {
 		var cfg myTestConfig
 		assert.Nil(t, provider.LoadConfigFromFile("./testdata/config/from-file.json", &cfg))
 		assert.Equal(t, "arn://messaging/kafka/clusters/c1", cfg.SampleRepo01.Resource)
-		assert.Len(t, provider.infraConfig.Messaging.Kafka.Clusters, 1)
-		cluster := provider.infraConfig.Messaging.Kafka.Clusters["c1"]
+		assert.Len(t, provider.Locator().Messaging.Kafka.Clusters, 1)
+		cluster := provider.Locator().Messaging.Kafka.Clusters["c1"]
 		assert.Equal(t, cluster.Username, "user")
 		assert.Equal(t, cluster.Password, "pAss=Word")
 	})
 }
+
+func TestProviderLoadConfigEmbedded(t *testing.T) {
+	provider, err := NewProvider(ProviderSettings{
+		EnvName:       "test",
+		SystemName:    "system",
+		ComponentName: "comp",
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	type Common struct {
+		Name    string `json:"name"`
+		Timeout int    `json:"timeout"`
+	}
+	var config struct {
+		Common
+		Workers int    `json:"workers"`
+		Nested  Common `json:"common"`
+	}
+	// the fields of embedded structures are read from the same object as the fields of the structure embedding them.
+	err = provider.LoadConfigFromTemplate([]byte(`{
+		"name": "app",
+		"timeout": 30,
+		"workers": 4,
+		"common": {"name": "nested"}
+	}`), &config)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "app", config.Name)
+		assert.Equal(t, 30, config.Timeout)
+		assert.Equal(t, 4, config.Workers)
+		assert.Equal(t, Common{Name: "nested"}, config.Nested)
+	}
+}
+
+func TestProviderLoadConfigDeepMerge(t *testing.T) {
+	type myTestConfig struct {
+		Repo struct {
+			Resource string `json:"arn"`
+			Params   struct {
+				Timeout            int `json:"timeout"`
+				MaxIdleConnections int `json:"max_idle_connections"`
+			} `json:"params"`
+		} `json:"repo"`
+		Hosts      []string `json:"hosts"`
+		Deprecated *struct {
+			Resource string `json:"arn"`
+		} `json:"deprecated"`
+	}
+
+	t.Run("replace arrays", func(t *testing.T) {
+		provider, err := NewProvider(ProviderSettings{
+			EnvName:       "testenv",
+			SystemName:    "sys",
+			ComponentName: "cmp",
+			ConfigMerge:   MergeDeep,
+		})
+		if !assert.Nil(t, err) {
+			t.FailNow()
+		}
+		var cfg myTestConfig
+		assert.Nil(t, provider.LoadConfig("merge", &cfg))
+		assert.Equal(t, "arn://storage/elasticsearch/sample-1", cfg.Repo.Resource)
+		assert.Equal(t, 15, cfg.Repo.Params.Timeout)
+		assert.Equal(t, 20, cfg.Repo.Params.MaxIdleConnections)
+		assert.Equal(t, []string{"c"}, cfg.Hosts)
+		assert.Nil(t, cfg.Deprecated)
+	})
+
+	t.Run("append arrays", func(t *testing.T) {
+		provider, err := NewProvider(ProviderSettings{
+			EnvName:       "testenv",
+			SystemName:    "sys",
+			ComponentName: "cmp",
+			ConfigMerge:   MergeDeep,
+			ArrayMerge:    ArrayAppend,
+		})
+		if !assert.Nil(t, err) {
+			t.FailNow()
+		}
+		var cfg myTestConfig
+		assert.Nil(t, provider.LoadConfig("merge", &cfg))
+		assert.Equal(t, []string{"a", "b", "c"}, cfg.Hosts)
+	})
+
+	t.Run("base only", func(t *testing.T) {
+		provider, err := NewProvider(ProviderSettings{
+			EnvName:       "test",
+			SystemName:    "sys",
+			ComponentName: "cmp",
+			ConfigMerge:   MergeDeep,
+		})
+		if !assert.Nil(t, err) {
+			t.FailNow()
+		}
+		var cfg myTestConfig
+		assert.Nil(t, provider.LoadConfig("merge", &cfg))
+		assert.Equal(t, 10, cfg.Repo.Params.Timeout)
+		assert.Equal(t, []string{"a", "b"}, cfg.Hosts)
+		if assert.NotNil(t, cfg.Deprecated) {
+			assert.Equal(t, "arn://storage/redis/sample-1", cfg.Deprecated.Resource)
+		}
+		assert.NotNil(t, provider.LoadConfig("does-not-exist", &cfg))
+	})
+
+	t.Run("effective config", func(t *testing.T) {
+		provider, err := NewProvider(ProviderSettings{
+			EnvName:       "testenv",
+			SystemName:    "sys",
+			ComponentName: "cmp",
+		})
+		if !assert.Nil(t, err) {
+			t.FailNow()
+		}
+		doc, err := provider.EffectiveConfig("merge")
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]interface{}{
+			"repo": map[string]interface{}{
+				"arn": "arn://storage/elasticsearch/sample-1",
+				"params": map[string]interface{}{
+					"timeout":              15.0,
+					"max_idle_connections": 20.0,
+				},
+			},
+			"hosts": []interface{}{"c"},
+		}, doc)
+	})
+}
+
+func TestProviderConfigFormats(t *testing.T) {
+	type myTestConfig struct {
+		Repo struct {
+			Resource string `json:"arn"`
+			Params   struct {
+				MaxIdleConns int `json:"max_idle_conns"`
+				MaxOpenConns int `json:"max_open_conns"`
+			} `json:"params"`
+		} `json:"repo"`
+		Hosts  []string `json:"hosts"`
+		Format string   `json:"format"`
+	}
+
+	for _, merge := range []ConfigMerge{MergeOverlay, MergeDeep} {
+		provider, err := NewProvider(ProviderSettings{
+			EnvName:       "yaml-tests",
+			SystemName:    "sys",
+			ComponentName: "cmp",
+			ConfigMerge:   merge,
+		})
+		if !assert.Nil(t, err) {
+			t.FailNow()
+		}
+		assert.Equal(t, "testdata/infra/yaml-tests.yml", provider.ResourcePath())
+
+		pg := provider.Locator().LocatePostgresResource("arn://storage/postgres/users")
+		assert.Nil(t, pg.Validate())
+		assert.Equal(t, "yaml-tests.db.local", pg.Host)
+		assert.Equal(t, uint16(5433), pg.Port)
+		assert.Equal(t, "sys-topic", provider.Locator().LocateKafkaClusterResource("arn://messaging/kafka/clusters/c1").TopicNameFor("topic"))
+
+		var cfg myTestConfig
+		assert.Nil(t, provider.LoadConfig("formats", &cfg))
+		assert.Equal(t, "arn://storage/postgres/users", cfg.Repo.Resource)
+		assert.Equal(t, 4, cfg.Repo.Params.MaxIdleConns)
+		assert.Equal(t, 16, cfg.Repo.Params.MaxOpenConns)
+		if merge == MergeDeep {
+			assert.Equal(t, []string{"cmp-c"}, cfg.Hosts)
+		}
+
+		cfg = myTestConfig{}
+		assert.Nil(t, provider.LoadConfig("precedence", &cfg))
+		assert.Equal(t, "json", cfg.Format)
+
+		cfg = myTestConfig{}
+		assert.Nil(t, provider.LoadConfig("formats-hcl", &cfg))
+		assert.Equal(t, "hcl", cfg.Format)
+		assert.Equal(t, []string{"a", "b"}, cfg.Hosts)
+
+		cfg = myTestConfig{}
+		assert.Nil(t, provider.LoadConfig("formats-nested", &cfg))
+		assert.Equal(t, "hcl", cfg.Format)
+		assert.Equal(t, "arn://storage/postgres/users", cfg.Repo.Resource)
+		assert.Equal(t, 4, cfg.Repo.Params.MaxIdleConns)
+		assert.Equal(t, 16, cfg.Repo.Params.MaxOpenConns)
+
+		cfg = myTestConfig{}
+		assert.Nil(t, provider.LoadConfigFromFile("testdata/config/formats-nested.hcl", &cfg))
+		assert.Equal(t, 16, cfg.Repo.Params.MaxOpenConns)
+	}
+}
@@ -0,0 +1,208 @@
+package infrastructure
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vredens/infrastructure/resources"
+)
+
+const (
+	// refKey is the key used in any object of the infrastructure configuration to replace it with the configuration
+	// of another resource, e.g. `"session": {"$ref": "arn://cloud/aws/account-1"}`. Other keys in the object override
+	// the ones of the referenced resource.
+	refKey = "$ref"
+	// aliasKey is the key used by a resource whose ARN is an alias of another resource, e.g.
+	// `"old-users": {"alias_of": "arn://storage/postgres/users"}`.
+	aliasKey = "alias_of"
+)
+
+// resolveReferences returns a new document with every alias and reference replaced by the configuration of the
+// resource it points to, along with the aliases found mapped to the ARN of the resources they resolve to.
+func resolveReferences(doc map[string]interface{}) (map[string]interface{}, map[string]string, error) {
+	doc = copyDocument(doc)
+	aliases := make(map[string]string)
+	if err := findAliases(doc, doc, nil, aliases); err != nil {
+		return nil, nil, err
+	}
+	// aliases always resolve to resources which are not aliases so they can be replaced in any order.
+	for alias, target := range aliases {
+		path, _ := pathOfARN(alias)
+		collection, _ := lookupPath(doc, path[:len(path)-1])
+		targetDoc, _ := lookupResource(doc, target)
+		resolved := copyDocument(targetDoc)
+		resolved[aliasKey] = target
+		collection[path[len(path)-1]] = resolved
+	}
+
+	resolved, err := resolveRefs(doc, doc, nil, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resolved.(map[string]interface{}), aliases, nil
+}
+
+// findAliases in the collections of resources, mapping their ARN to the one of the resource they resolve to.
+func findAliases(doc, value map[string]interface{}, path []string, aliases map[string]string) error {
+	if !resources.IsCollection(path...) {
+		for _, key := range sortedKeys(value) {
+			if child, ok := value[key].(map[string]interface{}); ok {
+				if err := findAliases(doc, child, append(path[:len(path):len(path)], key), aliases); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	for _, name := range sortedKeys(value) {
+		resource, ok := value[name].(map[string]interface{})
+		if !ok || resource[aliasKey] == nil {
+			continue
+		}
+		alias := arnOf(append(path[:len(path):len(path)], name))
+		target, err := aliasTarget(doc, path, alias)
+		if err != nil {
+			return err
+		}
+		aliases[alias] = target
+	}
+	return nil
+}
+
+// aliasTarget follows the chain of aliases starting at the alias, returning the ARN of the resource it resolves to.
+func aliasTarget(doc map[string]interface{}, collection []string, alias string) (string, error) {
+	chain := []string{alias}
+	current := alias
+	for {
+		resource, _ := lookupResource(doc, current)
+		if len(chain) > 1 && resource[aliasKey] == nil {
+			return current, nil
+		}
+		if len(resource) != 1 {
+			return "", fmt.Errorf("alias %s can only have %s", current, aliasKey)
+		}
+		next, ok := resource[aliasKey].(string)
+		if !ok {
+			return "", fmt.Errorf("invalid %s in %s; expected an arn", aliasKey, current)
+		}
+		path, err := pathOfARN(next)
+		if err != nil {
+			return "", fmt.Errorf("invalid %s in %s; %w", aliasKey, current, err)
+		}
+		if strings.Join(path[:len(path)-1], "/") != strings.Join(collection, "/") {
+			return "", fmt.Errorf("alias %s can only point to resources of the same kind, not %s", current, next)
+		}
+		for _, seen := range chain {
+			if seen == next {
+				return "", fmt.Errorf("alias cycle %s", strings.Join(append(chain, next), " -> "))
+			}
+		}
+		if _, found := lookupResource(doc, next); !found {
+			return "", fmt.Errorf("alias %s points to missing resource %s", current, next)
+		}
+		chain = append(chain, next)
+		current = next
+	}
+}
+
+// resolveRefs returns a copy of the value with every object with a reference replaced by the configuration of the
+// resource referenced. The stack holds the references being resolved for detecting cycles.
+func resolveRefs(doc map[string]interface{}, value interface{}, path []string, stack []string) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			if key == refKey {
+				continue
+			}
+			child, err := resolveRefs(doc, child, append(path[:len(path):len(path)], key), stack)
+			if err != nil {
+				return nil, err
+			}
+			resolved[key] = child
+		}
+		if v[refKey] == nil {
+			return resolved, nil
+		}
+
+		arn, ok := v[refKey].(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid %s in %s; expected an arn", refKey, strings.Join(path, "."))
+		}
+		for _, seen := range stack {
+			if seen == arn {
+				return nil, fmt.Errorf("reference cycle %s", strings.Join(append(stack, arn), " -> "))
+			}
+		}
+		target, found := lookupResource(doc, arn)
+		if !found {
+			return nil, fmt.Errorf("reference to missing resource %s in %s", arn, strings.Join(path, "."))
+		}
+		targetPath, _ := pathOfARN(arn)
+		referenced, err := resolveRefs(doc, target, targetPath, append(stack[:len(stack):len(stack)], arn))
+		if err != nil {
+			return nil, err
+		}
+		return mergeDocuments(referenced.(map[string]interface{}), resolved, ArrayReplace), nil
+	case []interface{}:
+		resolved := make([]interface{}, len(v))
+		for i, item := range v {
+			item, err := resolveRefs(doc, item, path, stack)
+			if err != nil {
+				return nil, err
+			}
+			resolved[i] = item
+		}
+		return resolved, nil
+	default:
+		return value, nil
+	}
+}
+
+// lookupResource returns the configuration of the resource with the ARN in the document.
+func lookupResource(doc map[string]interface{}, arn string) (map[string]interface{}, bool) {
+	path, err := pathOfARN(arn)
+	if err != nil {
+		return nil, false
+	}
+	return lookupPath(doc, path)
+}
+
+func lookupPath(doc map[string]interface{}, path []string) (map[string]interface{}, bool) {
+	current := doc
+	for _, key := range path {
+		next, ok := current[key].(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		current = next
+	}
+	return current, true
+}
+
+// pathOfARN in the infrastructure configuration document.
+func pathOfARN(arn string) ([]string, error) {
+	parsed, err := resources.Parse(arn)
+	if err != nil {
+		return nil, err
+	}
+	if parsed.Role() != "" {
+		return nil, fmt.Errorf("arn %s can not have a role", arn)
+	}
+	return append(append([]string{parsed.Group()}, parsed.Subgroups()...), parsed.Name()), nil
+}
+
+func arnOf(path []string) string {
+	return "arn://" + strings.Join(path, "/")
+}
+
+func sortedKeys(value map[string]interface{}) []string {
+	keys := make([]string, 0, len(value))
+	for key := range value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
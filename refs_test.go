@@ -0,0 +1,121 @@
+package infrastructure
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProviderReferences(t *testing.T) {
+	infraDir := t.TempDir()
+	writeFile(t, filepath.Join(infraDir, "test.json"), `{
+		"cloud": {"aws": {"account-1": {"region": "eu-west-1", "credentials": {"access_key_id": "key-1"}}}},
+		"storage": {
+			"s3": {
+				"assets": {"bucket": "assets", "session": {"$ref": "arn://cloud/aws/account-1"}},
+				"logs": {"bucket": "logs", "session": {"$ref": "arn://cloud/aws/account-1", "region": "us-east-1"}}
+			},
+			"dynamo": {"events": {"table": "events", "session": {"$ref": "arn://cloud/aws/account-1"}}},
+			"postgres": {
+				"users": {"host": "pg", "database": "users", "user": "app", "tags": ["datacenter:eu"]},
+				"old-users": {"alias_of": "arn://storage/postgres/users"},
+				"older-users": {"alias_of": "arn://storage/postgres/old-users"}
+			}
+		}
+	}`)
+
+	var deprecated []string
+	provider, err := NewProvider(ProviderSettings{
+		EnvName:            "test",
+		SystemName:         "sys",
+		ComponentName:      "cmp",
+		InfraConfigFolders: []string{infraDir},
+		OnDeprecatedARN: func(arn, replacement string) {
+			deprecated = append(deprecated, arn+" "+replacement)
+		},
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	locator := provider.Locator()
+	assets := locator.LocateS3ManagerResource("arn://storage/s3/assets")
+	assert.NoError(t, assets.Validate())
+	assert.Equal(t, "eu-west-1", assets.Session.Region)
+	assert.Equal(t, "key-1", assets.Session.Credentials.AccessKeyID)
+	assert.Equal(t, "us-east-1", locator.LocateS3ManagerResource("arn://storage/s3/logs").Session.Region)
+	assert.Equal(t, "eu-west-1", locator.LocateDynamoResource("arn://storage/dynamo/events").Session.Region)
+
+	for _, arn := range []string{"arn://storage/postgres/old-users", "arn://storage/postgres/older-users"} {
+		pg := locator.LocatePostgresResource(arn)
+		assert.NoError(t, pg.Validate())
+		assert.Equal(t, "pg", pg.Host)
+		assert.Equal(t, "arn://storage/postgres/users", pg.AliasOf)
+	}
+	assert.Empty(t, locator.LocatePostgresResource("arn://storage/postgres/users").AliasOf)
+	assert.Equal(t, []string{
+		"arn://storage/postgres/old-users arn://storage/postgres/users",
+		"arn://storage/postgres/older-users arn://storage/postgres/users",
+	}, deprecated)
+
+	// aliases are not listed along with the resource they resolve to.
+	entries, err := locator.Find("datacenter=eu")
+	if assert.NoError(t, err) && assert.Len(t, entries, 1) {
+		assert.Equal(t, "arn://storage/postgres/users", entries[0].ARN)
+	}
+	assert.Equal(t, map[string]string{
+		"arn://storage/postgres/old-users":   "arn://storage/postgres/users",
+		"arn://storage/postgres/older-users": "arn://storage/postgres/users",
+	}, provider.Aliases())
+}
+
+func TestProviderReferenceErrors(t *testing.T) {
+	testCases := map[string]struct {
+		content string
+		errMsg  string
+	}{
+		"dangling reference": {
+			content: `{"storage": {"s3": {"assets": {"bucket": "assets", "session": {"$ref": "arn://cloud/aws/missing"}}}}}`,
+			errMsg:  "reference to missing resource arn://cloud/aws/missing in storage.s3.assets.session",
+		},
+		"reference cycle": {
+			content: `{"cloud": {"aws": {
+				"a": {"region": "x", "credentials": {"$ref": "arn://cloud/aws/b"}},
+				"b": {"region": "y", "credentials": {"$ref": "arn://cloud/aws/a"}}
+			}}}`,
+			errMsg: "reference cycle",
+		},
+		"dangling alias": {
+			content: `{"storage": {"postgres": {"old": {"alias_of": "arn://storage/postgres/missing"}}}}`,
+			errMsg:  "alias arn://storage/postgres/old points to missing resource arn://storage/postgres/missing",
+		},
+		"alias cycle": {
+			content: `{"storage": {"postgres": {"a": {"alias_of": "arn://storage/postgres/b"}, "b": {"alias_of": "arn://storage/postgres/a"}}}}`,
+			errMsg:  "alias cycle arn://storage/postgres/a -> arn://storage/postgres/b -> arn://storage/postgres/a",
+		},
+		"alias of another kind": {
+			content: `{"storage": {"redis": {"cache": {"address": "redis:6379"}}, "postgres": {"a": {"alias_of": "arn://storage/redis/cache"}}}}`,
+			errMsg:  "alias arn://storage/postgres/a can only point to resources of the same kind",
+		},
+		"alias with settings": {
+			content: `{"storage": {"postgres": {"a": {"host": "pg"}, "b": {"alias_of": "arn://storage/postgres/a", "host": "other"}}}}`,
+			errMsg:  "alias arn://storage/postgres/b can only have alias_of",
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			infraDir := t.TempDir()
+			writeFile(t, filepath.Join(infraDir, "test.json"), tc.content)
+			_, err := NewProvider(ProviderSettings{
+				EnvName:            "test",
+				SystemName:         "sys",
+				ComponentName:      "cmp",
+				InfraConfigFolders: []string{infraDir},
+			})
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.errMsg)
+			}
+		})
+	}
+}
@@ -40,16 +40,5 @@ func (r Algolia) sanitize() Algolia {
 
 // LocateAlgoliaResource returns an AlgoliaResource definition.
 func (irl Locator) LocateAlgoliaResource(arn string) Algolia {
-	var resource Algolia
-	var found bool
-	var name, _, err = parse(arn, "storage", "algolia")
-	if err != nil {
-		resource.Resource.err = err
-		return resource
-	}
-	if resource, found = irl.Databases.Algolia[name]; !found {
-		resource.Resource.err = fmt.Errorf("algolia resource not found")
-		return resource
-	}
-	return resource.sanitize()
+	return Locate[Algolia](&irl, arn)
 }
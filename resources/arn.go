@@ -0,0 +1,77 @@
+package resources
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ARN (Application Resource Name) identifies a resource across environments.
+// The format is `arn://<group>[/<subgroup>...]/<name>[/<role>]`, e.g. `arn://storage/postgres/users/readonly`.
+type ARN struct {
+	group     string
+	subgroups []string
+	name      string
+	role      string
+}
+
+// Parse the ARN. The role segment is only recognized for ARNs of resources in the Locator, for any other ARN the last
+// segment is the name of the resource.
+func Parse(arn string) (ARN, error) {
+	if !strings.HasPrefix(arn, resourcePrefix) {
+		return ARN{}, fmt.Errorf("invalid arn %q; expected %s<group>[/<subgroup>]/<name>[/<role>]", arn, resourcePrefix)
+	}
+	segments := strings.Split(strings.TrimPrefix(arn, resourcePrefix), "/")
+	if len(segments) < 2 {
+		return ARN{}, fmt.Errorf("invalid arn %q; expected at least a group and a name", arn)
+	}
+	for _, segment := range segments {
+		if segment == "" {
+			return ARN{}, fmt.Errorf("invalid arn %q; empty segment", arn)
+		}
+	}
+
+	// the longest path to a collection of resources tells where the name is.
+	for i := len(segments) - 1; i > 0; i-- {
+		if !IsCollection(segments[:i]...) {
+			continue
+		}
+		if len(segments)-i > 2 {
+			return ARN{}, fmt.Errorf("invalid arn %q; too many segments after the name", arn)
+		}
+		parsed := ARN{group: segments[0], subgroups: segments[1:i], name: segments[i]}
+		if len(segments)-i == 2 {
+			parsed.role = segments[i+1]
+		}
+		return parsed, nil
+	}
+	return ARN{group: segments[0], subgroups: segments[1 : len(segments)-1], name: segments[len(segments)-1]}, nil
+}
+
+// String returns the ARN in the `arn://` format.
+func (arn ARN) String() string {
+	segments := append(append([]string{arn.group}, arn.subgroups...), arn.name)
+	if arn.role != "" {
+		segments = append(segments, arn.role)
+	}
+	return resourcePrefix + strings.Join(segments, "/")
+}
+
+// Group is the first segment of the ARN, e.g. `storage`.
+func (arn ARN) Group() string {
+	return arn.group
+}
+
+// Subgroups are the segments between the group and the name, e.g. `[postgres]`.
+func (arn ARN) Subgroups() []string {
+	return append([]string(nil), arn.subgroups...)
+}
+
+// Name of the resource.
+func (arn ARN) Name() string {
+	return arn.name
+}
+
+// Role segment of the ARN, if any.
+func (arn ARN) Role() string {
+	return arn.role
+}
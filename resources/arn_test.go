@@ -0,0 +1,72 @@
+package resources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestARN(t *testing.T) {
+	testCases := []struct {
+		arn       string
+		group     string
+		subgroups []string
+		name      string
+		role      string
+	}{
+		{arn: "arn://storage/postgres/users", group: "storage", subgroups: []string{"postgres"}, name: "users"},
+		{arn: "arn://storage/postgres/users/readonly", group: "storage", subgroups: []string{"postgres"}, name: "users", role: "readonly"},
+		{arn: "arn://messaging/kafka/clusters/main", group: "messaging", subgroups: []string{"kafka", "clusters"}, name: "main"},
+		{arn: "arn://webservices/api", group: "webservices", name: "api"},
+		{arn: "arn://custom/things/thing", group: "custom", subgroups: []string{"things"}, name: "thing"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.arn, func(t *testing.T) {
+			arn, err := Parse(tc.arn)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, tc.group, arn.Group())
+			assert.Equal(t, tc.subgroups, arn.Subgroups())
+			assert.Equal(t, tc.name, arn.Name())
+			assert.Equal(t, tc.role, arn.Role())
+			assert.Equal(t, tc.arn, arn.String())
+		})
+	}
+
+	for _, invalid := range []string{"", "storage/postgres/users", "arn://storage", "arn://storage//users", "arn://storage/postgres/users/readonly/extra"} {
+		_, err := Parse(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestLocatePostgresRole(t *testing.T) {
+	locator := Locator{Databases: Databases{Postgres: map[string]Postgres{
+		"users": {
+			Host:     "pg",
+			Database: "users",
+			User:     "app",
+			Password: "app-pass",
+			Roles: map[string]PostgresRole{
+				"readonly": {User: "reporting", Password: "reporting-pass"},
+			},
+		},
+	}}}
+
+	base := locator.LocatePostgresResource("arn://storage/postgres/users")
+	assert.NoError(t, base.Validate())
+	assert.Equal(t, "app", base.User)
+
+	readonly := locator.LocatePostgresResource("arn://storage/postgres/users/readonly")
+	assert.NoError(t, readonly.Validate())
+	assert.Equal(t, "reporting", readonly.User)
+	assert.Equal(t, "reporting-pass", readonly.Password)
+	assert.Equal(t, "pg", readonly.Host)
+	assert.Equal(t, "users", readonly.Database)
+	assert.Equal(t, uint16(5432), readonly.Port)
+
+	missing := locator.LocatePostgresResource("arn://storage/postgres/users/admin")
+	if assert.Error(t, missing.Validate()) {
+		assert.Contains(t, missing.Validate().Error(), "postgres role admin not defined")
+	}
+}
@@ -24,16 +24,5 @@ func (r Dynamo) sanitize() Dynamo {
 
 // LocateDynamoResource ...
 func (irl *Locator) LocateDynamoResource(arn string) Dynamo {
-	var resource Dynamo
-	var found bool
-	var name, _, err = parse(arn, "storage", "dynamo")
-	if err != nil {
-		resource.Resource.err = err
-		return resource
-	}
-	if resource, found = irl.Databases.Dynamo[name]; !found {
-		resource.Resource.err = ErrResourceNotFound
-		return resource
-	}
-	return resource.sanitize()
+	return Locate[Dynamo](irl, arn)
 }
@@ -11,11 +11,12 @@ type Kinesis struct {
 // KinesisConsumer resource data structure.
 type KinesisConsumer struct {
 	Resource
-	AWS struct {
-		Endpoint string `json:"endpoint"`
-		Region   string `json:"region"`
-	} `json:"aws"`
-	Stream string `json:"stream"`
+	Stream  string     `json:"stream"`
+	Session AWSSession `json:"session"`
+	// AWS endpoint and region, used when the Session does not set them.
+	//
+	// Deprecated: use the Session.
+	AWS AWSEndpoint `json:"aws"`
 }
 
 // Validate returns true if the resource is valid.
@@ -26,38 +27,32 @@ func (r KinesisConsumer) Validate() error {
 	if r.Stream == "" {
 		return fmt.Errorf("kinesis consumer stream can not be empty")
 	}
+	if err := r.Session.Validate(); err != nil {
+		return err
+	}
 	return r.err
 }
 
 func (r KinesisConsumer) sanitize() KinesisConsumer {
+	r.Session, r.AWS = r.Session.withEndpoint(r.AWS)
 	r.err = r.Validate()
 	return r
 }
 
 // LocateKinesisConsumerResource definition.
 func (irl *Locator) LocateKinesisConsumerResource(arn string) KinesisConsumer {
-	var resource KinesisConsumer
-	var found bool
-	var name, _, err = parse(arn, "messaging", "kinesis", "consumers")
-	if err != nil {
-		resource.Resource.err = err
-		return resource
-	}
-	if resource, found = irl.Messaging.Kinesis.Consumers[name]; !found {
-		resource.Resource.err = ErrResourceNotFound
-		return resource
-	}
-	return resource.sanitize()
+	return Locate[KinesisConsumer](irl, arn)
 }
 
 // KinesisProducer resource data structure.
 type KinesisProducer struct {
 	Resource
-	AWS struct {
-		Endpoint string `json:"endpoint"`
-		Region   string `json:"region"`
-	} `json:"aws"`
-	Stream string `json:"stream"`
+	Stream  string     `json:"stream"`
+	Session AWSSession `json:"session"`
+	// AWS endpoint and region, used when the Session does not set them.
+	//
+	// Deprecated: use the Session.
+	AWS AWSEndpoint `json:"aws"`
 }
 
 // Validate returns true if the resource is valid.
@@ -68,26 +63,19 @@ func (r KinesisProducer) Validate() error {
 	if r.Stream == "" {
 		return fmt.Errorf("kinesis producer stream can not be empty")
 	}
+	if err := r.Session.Validate(); err != nil {
+		return err
+	}
 	return r.err
 }
 
 func (r KinesisProducer) sanitize() KinesisProducer {
+	r.Session, r.AWS = r.Session.withEndpoint(r.AWS)
 	r.err = r.Validate()
 	return r
 }
 
 // LocateKinesisProducerResource definition.
 func (irl *Locator) LocateKinesisProducerResource(arn string) KinesisProducer {
-	var resource KinesisProducer
-	var found bool
-	var name, _, err = parse(arn, "messaging", "kinesis", "producers")
-	if err != nil {
-		resource.Resource.err = err
-		return resource
-	}
-	if resource, found = irl.Messaging.Kinesis.Producers[name]; !found {
-		resource.Resource.err = ErrResourceNotFound
-		return resource
-	}
-	return resource.sanitize()
+	return Locate[KinesisProducer](irl, arn)
 }
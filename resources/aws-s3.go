@@ -30,16 +30,5 @@ func (r S3Manager) sanitize() S3Manager {
 
 // LocateS3ManagerResource ...
 func (irl Locator) LocateS3ManagerResource(arn string) S3Manager {
-	var resource S3Manager
-	var found bool
-	var name, _, err = parse(arn, "storage", "s3")
-	if err != nil {
-		resource.Resource.err = err
-		return resource
-	}
-	if resource, found = irl.Databases.S3[name]; !found {
-		resource.Resource.err = ErrResourceNotFound
-		return resource
-	}
-	return resource.sanitize()
+	return Locate[S3Manager](&irl, arn)
 }
@@ -11,11 +11,12 @@ type SQSResource struct {
 // SQSConsumerResource data structure.
 type SQSConsumerResource struct {
 	Resource
-	AWS struct {
-		Endpoint string `json:"endpoint"`
-		Region   string `json:"region"`
-	} `json:"aws"`
-	Queue string `json:"queue"`
+	Queue   string     `json:"queue"`
+	Session AWSSession `json:"session"`
+	// AWS endpoint and region, used when the Session does not set them.
+	//
+	// Deprecated: use the Session.
+	AWS AWSEndpoint `json:"aws"`
 }
 
 // Validate returns true if the resource is valid.
@@ -26,38 +27,32 @@ func (r SQSConsumerResource) Validate() error {
 	if r.Queue == "" {
 		return fmt.Errorf("sqs consumer queue can not be empty")
 	}
+	if err := r.Session.Validate(); err != nil {
+		return err
+	}
 	return r.err
 }
 
 func (r SQSConsumerResource) sanitize() SQSConsumerResource {
+	r.Session, r.AWS = r.Session.withEndpoint(r.AWS)
 	r.err = r.Validate()
 	return r
 }
 
 // LocateSQSConsumerResource definition.
 func (irl *Locator) LocateSQSConsumerResource(arn string) SQSConsumerResource {
-	var resource SQSConsumerResource
-	var found bool
-	var name, _, err = parse(arn, "messaging", "sqs", "consumers")
-	if err != nil {
-		resource.Resource.err = err
-		return resource
-	}
-	if resource, found = irl.Messaging.SQS.Consumers[name]; !found {
-		resource.Resource.err = ErrResourceNotFound
-		return resource
-	}
-	return resource.sanitize()
+	return Locate[SQSConsumerResource](irl, arn)
 }
 
 // SQSProducerResource data structure.
 type SQSProducerResource struct {
 	Resource
-	AWS struct {
-		Endpoint string `json:"endpoint"`
-		Region   string `json:"region"`
-	} `json:"aws"`
-	Queue string `json:"queue"`
+	Queue   string     `json:"queue"`
+	Session AWSSession `json:"session"`
+	// AWS endpoint and region, used when the Session does not set them.
+	//
+	// Deprecated: use the Session.
+	AWS AWSEndpoint `json:"aws"`
 }
 
 // Validate returns true if the resource is valid.
@@ -68,26 +63,19 @@ func (r SQSProducerResource) Validate() error {
 	if r.Queue == "" {
 		return fmt.Errorf("sqs producer queue can not be empty")
 	}
+	if err := r.Session.Validate(); err != nil {
+		return err
+	}
 	return r.err
 }
 
 func (r SQSProducerResource) sanitize() SQSProducerResource {
+	r.Session, r.AWS = r.Session.withEndpoint(r.AWS)
 	r.err = r.Validate()
 	return r
 }
 
 // LocateSQSProducerResource definition.
 func (irl *Locator) LocateSQSProducerResource(arn string) SQSProducerResource {
-	var resource SQSProducerResource
-	var found bool
-	var name, _, err = parse(arn, "messaging", "sqs", "producers")
-	if err != nil {
-		resource.Resource.err = err
-		return resource
-	}
-	if resource, found = irl.Messaging.SQS.Producers[name]; !found {
-		resource.Resource.err = ErrResourceNotFound
-		return resource
-	}
-	return resource.sanitize()
+	return Locate[SQSProducerResource](irl, arn)
 }
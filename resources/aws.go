@@ -1,34 +1,70 @@
 package resources
 
+import "fmt"
+
 // AWSSession defines the configuration for an aws session.
+//
+// The session of resources using AWS, such as S3Manager, KinesisConsumer or SQSProducerResource, can be a reference to
+// one of the sessions of the configuration, e.g. `"session": {"$ref": "arn://cloud/aws/<name>"}`.
 type AWSSession struct {
 	Resource
-	Endpoint                  string         `json:"endpoint"`
-	Region                    string         `json:"region"`
-	Role                      string         `json:"role"`
+	Endpoint string `json:"endpoint"`
+	Region   string `json:"region"`
+	// Profile of the shared configuration and credentials files of the AWS CLI.
+	Profile string `json:"profile"`
+	// Role to assume, by its ARN.
+	Role string `json:"role"`
+	// ExternalID required by the trust policy of the Role, if any.
+	ExternalID string `json:"external_id"`
+	// SessionName of the Role session. Defaults to one generated by the SDK.
+	SessionName string `json:"session_name"`
+	// WebIdentityTokenFile with the token for assuming the Role through web identity federation, such as the token
+	// file of an EKS service account.
+	WebIdentityTokenFile      string         `json:"web_identity_token_file"`
 	Credentials               AWSCredentials `json:"credentials"`
 	DisableSSL                bool           `json:"disable_ssl"`
 	S3ForcePathStyle          bool           `json:"force_path_style"`
 	DisableEndpointHostPrefix bool           `json:"disable_endpoint_host_prefix"`
 }
 
+// Validate returns an error if the session is invalid.
+func (a AWSSession) Validate() error {
+	if a.err != nil {
+		return a.err
+	}
+	if a.Role == "" && (a.ExternalID != "" || a.SessionName != "" || a.WebIdentityTokenFile != "") {
+		return fmt.Errorf("aws session role configuration undefined")
+	}
+	return nil
+}
+
 func (a AWSSession) sanitize() AWSSession {
+	a.err = a.Validate()
 	return a
 }
 
 func (irl Locator) LocateAWSSession(arn string) AWSSession {
-	var resource AWSSession
-	var found bool
-	var name, _, err = parse(arn, "cloud", "aws")
-	if err != nil {
-		resource.Resource.err = err
-		return resource
+	return Locate[AWSSession](&irl, arn)
+}
+
+// AWSEndpoint is the endpoint and region of the `aws` block of messaging resources, which predates their session.
+//
+// Deprecated: use the session of the resources.
+type AWSEndpoint struct {
+	Endpoint string `json:"endpoint"`
+	Region   string `json:"region"`
+}
+
+// withEndpoint returns the session with the endpoint and region it lacks taken from the legacy `aws` block, and the
+// `aws` block with the endpoint and region of the session, so both agree.
+func (a AWSSession) withEndpoint(legacy AWSEndpoint) (AWSSession, AWSEndpoint) {
+	if a.Endpoint == "" {
+		a.Endpoint = legacy.Endpoint
 	}
-	if resource, found = irl.Cloud.AWS[name]; !found {
-		resource.Resource.err = ErrResourceNotFound
-		return resource
+	if a.Region == "" {
+		a.Region = legacy.Region
 	}
-	return resource.sanitize()
+	return a, AWSEndpoint{Endpoint: a.Endpoint, Region: a.Region}
 }
 
 // AWSCredentials defines the credentials configuration.
@@ -29,16 +29,5 @@ func (r Elasticsearch) sanitize() Elasticsearch {
 
 // LocateElasticResource returns an ElasticsearchResource definition.
 func (irl *Locator) LocateElasticResource(arn string) Elasticsearch {
-	var resource Elasticsearch
-	var found bool
-	var name, _, err = parse(arn, "storage", "elasticsearch")
-	if err != nil {
-		resource.Resource.err = err
-		return resource
-	}
-	if resource, found = irl.Databases.Elasticsearch[name]; !found {
-		resource.Resource.err = fmt.Errorf("elasticsearch resource not found")
-		return resource
-	}
-	return resource.sanitize()
+	return Locate[Elasticsearch](irl, arn)
 }
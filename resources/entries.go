@@ -0,0 +1,129 @@
+package resources
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Validator is implemented by every resource.
+type Validator interface {
+	Validate() error
+}
+
+// Entry of a resource in the Locator.
+type Entry struct {
+	// ARN of the resource, e.g. `arn://storage/postgres/users`.
+	ARN string
+	// Kind of resource, which is the name of its type, e.g. `Postgres` or `KafkaCluster`.
+	Kind string
+	// Tags of the resource.
+	Tags []string
+	// Resource configuration, e.g. a Postgres value.
+	Resource Validator
+}
+
+// All resources in the Locator, sorted by ARN, located just like Locate does. Aliases are left out as they resolve to
+// resources which are already part of the list.
+func (irl *Locator) All() []Entry {
+	return irl.entries(false)
+}
+
+// Lookup the resource with the ARN, which may be an alias, without reporting it. Returns false if no such resource
+// exists.
+func (irl *Locator) Lookup(arn string) (Entry, bool) {
+	for _, entry := range irl.entries(true) {
+		if entry.ARN == arn {
+			return entry, true
+		}
+	}
+	return Entry{}, false
+}
+
+func (irl *Locator) entries(aliases bool) []Entry {
+	var entries []Entry
+	for path, t := range collections {
+		iter := irl.collection(path).MapRange()
+		for iter.Next() {
+			if !aliases && iter.Value().FieldByName("Resource").Interface().(Resource).AliasOf != "" {
+				continue
+			}
+			arn := resourcePrefix + path + "/" + iter.Key().String()
+			var resource Validator = &Resource{err: fmt.Errorf("resource type %s can not be located", t)}
+			if locate, found := locators[t]; found {
+				resource = locate(irl, arn)
+			}
+			entries = append(entries, Entry{
+				ARN:      arn,
+				Kind:     t.Name(),
+				Tags:     iter.Value().FieldByName("Resource").Interface().(Resource).Tags,
+				Resource: resource,
+			})
+		}
+	}
+	for _, path := range registeredPaths() {
+		for name := range irl.section(path) {
+			arn := resourcePrefix + path + "/" + name
+			resource := irl.locateRegistered(arn, false)
+			if !aliases && resource.base().AliasOf != "" {
+				continue
+			}
+			entries = append(entries, Entry{
+				ARN:      arn,
+				Kind:     reflect.TypeOf(resource).Elem().Name(),
+				Tags:     resource.base().Tags,
+				Resource: resource,
+			})
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].ARN < entries[j].ARN })
+	return entries
+}
+
+// locators of each type of resource in the Locator.
+var locators = map[reflect.Type]func(irl *Locator, arn string) Validator{
+	reflect.TypeOf(AWSSession{}):          locatorOf[AWSSession],
+	reflect.TypeOf(Algolia{}):             locatorOf[Algolia],
+	reflect.TypeOf(Dynamo{}):              locatorOf[Dynamo],
+	reflect.TypeOf(Elasticsearch{}):       locatorOf[Elasticsearch],
+	reflect.TypeOf(KafkaCluster{}):        locatorOf[KafkaCluster],
+	reflect.TypeOf(KinesisConsumer{}):     locatorOf[KinesisConsumer],
+	reflect.TypeOf(KinesisProducer{}):     locatorOf[KinesisProducer],
+	reflect.TypeOf(NSQConsumer{}):         locatorOf[NSQConsumer],
+	reflect.TypeOf(NSQProducer{}):         locatorOf[NSQProducer],
+	reflect.TypeOf(Postgres{}):            locatorOf[Postgres],
+	reflect.TypeOf(Redis{}):               locatorOf[Redis],
+	reflect.TypeOf(S3Manager{}):           locatorOf[S3Manager],
+	reflect.TypeOf(SFTP{}):                locatorOf[SFTP],
+	reflect.TypeOf(SQSConsumerResource{}): locatorOf[SQSConsumerResource],
+	reflect.TypeOf(SQSProducerResource{}): locatorOf[SQSProducerResource],
+	reflect.TypeOf(Webservice{}):          locatorOf[Webservice],
+}
+
+func locatorOf[T Validator](irl *Locator, arn string) Validator {
+	return locate[T](irl, arn, false)
+}
+
+// ValidateAll resources in the Locator, as located by Locate. The error returned joins the errors of every invalid
+// resource, along with its ARN.
+func (irl *Locator) ValidateAll() error {
+	var errs []error
+	for _, entry := range irl.All() {
+		if err := entry.Resource.Validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", entry.ARN, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// fieldByTag returns the field of the struct with the json name.
+func fieldByTag(value reflect.Value, name string) reflect.Value {
+	for i := 0; i < value.NumField(); i++ {
+		if tag, _, _ := strings.Cut(value.Type().Field(i).Tag.Get("json"), ","); tag == name {
+			return value.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
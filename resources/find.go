@@ -0,0 +1,94 @@
+package resources
+
+import (
+	"fmt"
+	"strings"
+)
+
+// selector of resources by one of their tags.
+type selector struct {
+	key     string
+	value   string
+	any     bool
+	negated bool
+}
+
+// matches returns true if the tags satisfy the selector. Tags are in the format `key:value` or `key`.
+func (s selector) matches(tags []string) bool {
+	found := false
+	for _, tag := range tags {
+		key, value, _ := strings.Cut(tag, ":")
+		if key == s.key && (s.any || value == s.value) {
+			found = true
+			break
+		}
+	}
+	return found != s.negated
+}
+
+// parseQuery of comma separated tag selectors.
+func parseQuery(query string) ([]selector, error) {
+	var selectors []selector
+	if strings.TrimSpace(query) == "" {
+		return selectors, nil
+	}
+	for _, term := range strings.Split(query, ",") {
+		term = strings.TrimSpace(term)
+		var s selector
+		if strings.HasPrefix(term, "!") {
+			s.negated = true
+			term = strings.TrimSpace(term[1:])
+		}
+		var hasValue bool
+		s.key, s.value, hasValue = strings.Cut(term, "=")
+		s.key, s.value, s.any = strings.TrimSpace(s.key), strings.TrimSpace(s.value), !hasValue
+		if s.key == "" {
+			return nil, fmt.Errorf("invalid query %q; empty tag selector", query)
+		}
+		selectors = append(selectors, s)
+	}
+	return selectors, nil
+}
+
+// Find resources whose tags match the query, sorted by ARN, optionally only of the given kinds (e.g. "Postgres").
+// Resources are located just like All does.
+//
+// The query is a comma separated list of tag selectors which must all match:
+//   - `key=value` matches resources with the tag `key:value`;
+//   - `key` matches resources with a tag `key` or `key:<any value>`;
+//   - a selector starting with `!` matches resources which do not match it, e.g. `!state=discontinued`.
+//
+// An empty query matches every resource.
+func (irl *Locator) Find(query string, kinds ...string) ([]Entry, error) {
+	selectors, err := parseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []Entry
+	for _, entry := range irl.All() {
+		if len(kinds) > 0 && !containsFold(kinds, entry.Kind) {
+			continue
+		}
+		matches := true
+		for _, s := range selectors {
+			if !s.matches(entry.Tags) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			found = append(found, entry)
+		}
+	}
+	return found, nil
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -9,12 +9,30 @@ type Kafka struct {
 	Clusters map[string]KafkaCluster `json:"clusters"`
 }
 
+// Kafka security protocols.
+const (
+	KafkaPlaintext     = "PLAINTEXT"
+	KafkaSSL           = "SSL"
+	KafkaSASLPlaintext = "SASL_PLAINTEXT"
+	KafkaSASLSSL       = "SASL_SSL"
+)
+
+// Kafka SASL mechanisms.
+const (
+	KafkaPlain       = "PLAIN"
+	KafkaScramSHA256 = "SCRAM-SHA-256"
+	KafkaScramSHA512 = "SCRAM-SHA-512"
+	KafkaOAuthBearer = "OAUTHBEARER"
+)
+
 // KafkaCluster data structure.
 type KafkaCluster struct {
 	Resource
-	Brokers          []string          `json:"brokers"`
+	Brokers []string `json:"brokers"`
+	// Username and Password for SASL authentication with the PLAIN or SCRAM mechanisms.
 	Username         string            `json:"username"`
 	Password         string            `json:"password"`
+	Security         KafkaSecurity     `json:"security"`
 	TopicPrefix      string            `json:"topic_prefix"`
 	TopicSuffix      string            `json:"topic_suffix"`
 	GroupPrefix      string            `json:"group_prefix"`
@@ -22,6 +40,47 @@ type KafkaCluster struct {
 	TopicTranslation map[string]string `json:"topic_translation"`
 }
 
+// KafkaSecurity settings of a Kafka cluster.
+type KafkaSecurity struct {
+	// Protocol is KafkaPlaintext, KafkaSSL, KafkaSASLPlaintext or KafkaSASLSSL.
+	// Defaults to KafkaSASLPlaintext for clusters with a Username and to KafkaPlaintext otherwise.
+	Protocol string `json:"protocol"`
+	// Mechanism is KafkaPlain, KafkaScramSHA256, KafkaScramSHA512 or KafkaOAuthBearer. Defaults to KafkaPlain.
+	Mechanism string `json:"mechanism"`
+	// CAFile with the certificates of the authorities trusted to sign the certificates of the brokers.
+	// Defaults to the certificates of the Provider, see ProviderSettings.CertFolders.
+	CAFile string `json:"ca_file"`
+	// CertFile with the client certificate, for clusters authenticating clients through TLS.
+	CertFile string `json:"cert_file"`
+	// KeyFile with the key of the client certificate.
+	KeyFile string `json:"key_file"`
+	// OAuth2 client credentials for obtaining tokens with the KafkaOAuthBearer mechanism.
+	OAuth2 OAuth2Credentials `json:"oauth2"`
+}
+
+// SecurityProtocol of the cluster, which defaults to KafkaSASLPlaintext if the cluster has both a Username and a
+// Password or to KafkaPlaintext otherwise.
+func (r KafkaCluster) SecurityProtocol() string {
+	if r.Security.Protocol != "" {
+		return r.Security.Protocol
+	}
+	if r.Username != "" && r.Password != "" {
+		return KafkaSASLPlaintext
+	}
+	return KafkaPlaintext
+}
+
+// SASLMechanism of the cluster. Empty if the security protocol does not use SASL.
+func (r KafkaCluster) SASLMechanism() string {
+	if protocol := r.SecurityProtocol(); protocol != KafkaSASLPlaintext && protocol != KafkaSASLSSL {
+		return ""
+	}
+	if r.Security.Mechanism == "" {
+		return KafkaPlain
+	}
+	return r.Security.Mechanism
+}
+
 // Validate returns true if the resource is valid.
 func (r KafkaCluster) Validate() error {
 	if r.err != nil {
@@ -30,6 +89,27 @@ func (r KafkaCluster) Validate() error {
 	if len(r.Brokers) <= 0 {
 		return fmt.Errorf("kafka consumer brokers can not be empty")
 	}
+	switch protocol := r.SecurityProtocol(); protocol {
+	case KafkaPlaintext, KafkaSSL, KafkaSASLPlaintext, KafkaSASLSSL:
+	default:
+		return fmt.Errorf("kafka security protocol %s is invalid", protocol)
+	}
+	switch mechanism := r.SASLMechanism(); mechanism {
+	case "":
+	case KafkaPlain, KafkaScramSHA256, KafkaScramSHA512:
+		if r.Username == "" || r.Password == "" {
+			return fmt.Errorf("kafka %s authentication requires a username and password", mechanism)
+		}
+	case KafkaOAuthBearer:
+		if r.Security.OAuth2.TokenURL == "" || r.Security.OAuth2.ClientID == "" || r.Security.OAuth2.ClientSecret == "" {
+			return fmt.Errorf("kafka %s authentication requires oauth2 token_url, client_id and client_secret", mechanism)
+		}
+	default:
+		return fmt.Errorf("kafka sasl mechanism %s is invalid", mechanism)
+	}
+	if (r.Security.CertFile == "") != (r.Security.KeyFile == "") {
+		return fmt.Errorf("kafka security requires both cert_file and key_file")
+	}
 	return r.err
 }
 
@@ -53,16 +133,5 @@ func (r KafkaCluster) sanitize() KafkaCluster {
 
 // LocateKafkaClusterResource definition.
 func (irl Locator) LocateKafkaClusterResource(arn string) KafkaCluster {
-	var resource KafkaCluster
-	var found bool
-	var name, _, err = parse(arn, "messaging", "kafka", "clusters")
-	if err != nil {
-		resource.Resource.err = err
-		return resource
-	}
-	if resource, found = irl.Messaging.Kafka.Clusters[name]; !found {
-		resource.Resource.err = ErrResourceNotFound
-		return resource
-	}
-	return resource.sanitize()
+	return Locate[KafkaCluster](&irl, arn)
 }
@@ -0,0 +1,99 @@
+package resources
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Locate the resource of type T pointed to by the ARN. T is either one of the resource types of the Locator, e.g.
+// `resources.Locate[resources.Postgres](locator, arn)`, or the pointer type of a type registered with RegisterType,
+// e.g. `resources.Locate[*MongoDB](locator, arn)`.
+// Errors locating the resource are returned by its Validate method.
+// Locating an alias is reported to the provider of the Locator, see Resource.AliasOf, if it has a method
+// `DeprecatedARN(arn, replacement string)`.
+func Locate[T Validator](irl *Locator, arn string) T {
+	return locate[T](irl, arn, true)
+}
+
+func locate[T Validator](irl *Locator, arn string, report bool) T {
+	var resource T
+	t := reflect.TypeOf(&resource).Elem()
+
+	path, found := pathsByType[t]
+	if !found {
+		if _, registered := registeredPathOf(t); !registered {
+			return withError(resource, fmt.Errorf("%s is not a resource type", t))
+		}
+		located := irl.locateRegistered(arn, report)
+		if typed, ok := located.(T); ok {
+			return typed
+		}
+		if err := located.Validate(); err != nil {
+			return withError(resource, err)
+		}
+		return withError(resource, fmt.Errorf("resource %s is a %T, not a %s", arn, located, t))
+	}
+
+	name, role, err := parse(arn, strings.Split(path, "/")...)
+	if err != nil {
+		return withError(resource, err)
+	}
+	value := irl.collection(path).MapIndex(reflect.ValueOf(name))
+	if !value.IsValid() {
+		return withError(resource, ErrResourceNotFound)
+	}
+	resource = value.Interface().(T)
+	if report {
+		irl.reportAlias(arn, value.FieldByName("Resource").Interface().(Resource))
+	}
+
+	if role != "" {
+		if withRole, ok := any(resource).(interface{ withRole(string) (T, error) }); ok {
+			if resource, err = withRole.withRole(role); err != nil {
+				return withError(resource, err)
+			}
+		}
+	}
+	if sanitizer, ok := any(resource).(interface{ sanitize() T }); ok {
+		resource = sanitizer.sanitize()
+	}
+	return resource
+}
+
+// reportAlias to the provider if the resource located with the ARN is an alias.
+func (irl *Locator) reportAlias(arn string, resource Resource) {
+	if resource.AliasOf == "" {
+		return
+	}
+	if reporter, ok := irl.provider.(interface{ DeprecatedARN(arn, replacement string) }); ok {
+		reporter.DeprecatedARN(arn, resource.AliasOf)
+	}
+}
+
+// collection of resources at the path, e.g. "storage/postgres".
+func (irl *Locator) collection(path string) reflect.Value {
+	value := reflect.ValueOf(irl).Elem()
+	for _, name := range strings.Split(path, "/") {
+		value = fieldByTag(value, name)
+	}
+	return value
+}
+
+// withError returns the resource with the error, which is returned by its Validate method.
+func withError[T any](resource T, err error) T {
+	if base, ok := any(&resource).(interface{ base() *Resource }); ok {
+		base.base().err = err
+		return resource
+	}
+	value := reflect.ValueOf(&resource).Elem()
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			value.Set(reflect.New(value.Type().Elem()))
+		}
+		if base, ok := value.Interface().(interface{ base() *Resource }); ok {
+			base.base().err = err
+		}
+	}
+	return resource
+}
@@ -2,6 +2,7 @@ package resources
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 )
 
@@ -33,7 +34,10 @@ type Locator struct {
 	Databases   Databases             `json:"storage"`
 	Messaging   Messaging             `json:"messaging"`
 	Webservices map[string]Webservice `json:"webservices"`
-	provider    Provider
+	// Extra holds the sections of the configuration which are not part of the structure, such as the ones of types
+	// registered with RegisterType. Cloud, Databases and Messaging hold theirs the same way.
+	Extra    map[string]interface{} `json:",remain"`
+	provider Provider
 }
 
 func (loc *Locator) SetProvider(provider Provider) {
@@ -41,7 +45,8 @@ func (loc *Locator) SetProvider(provider Provider) {
 }
 
 type Cloud struct {
-	AWS map[string]AWSSession `json:"aws"`
+	AWS   map[string]AWSSession  `json:"aws"`
+	Extra map[string]interface{} `json:",remain"`
 }
 
 // Databases configuration datastructure.
@@ -53,14 +58,61 @@ type Databases struct {
 	S3            map[string]S3Manager     `json:"s3"`
 	SFTP          map[string]SFTP          `json:"sftp"`
 	Dynamo        map[string]Dynamo        `json:"dynamo"`
+	Extra         map[string]interface{}   `json:",remain"`
 }
 
 // Messaging configuration datastructure.
 type Messaging struct {
-	NSQ     NSQ         `json:"nsq"`
-	Kinesis Kinesis     `json:"kinesis"`
-	Kafka   Kafka       `json:"kafka"`
-	SQS     SQSResource `json:"sqs"`
+	NSQ     NSQ                    `json:"nsq"`
+	Kinesis Kinesis                `json:"kinesis"`
+	Kafka   Kafka                  `json:"kafka"`
+	SQS     SQSResource            `json:"sqs"`
+	Extra   map[string]interface{} `json:",remain"`
+}
+
+// collections holds the paths, e.g. "storage/postgres", of every map of resources in the Locator along with the type
+// of the resources.
+var collections = collectionsOf(reflect.TypeOf(Locator{}), "")
+
+// pathsByType holds the path of the collection of each type of resource in the Locator.
+var pathsByType = func() map[reflect.Type]string {
+	paths := make(map[reflect.Type]string, len(collections))
+	for path, t := range collections {
+		paths[t] = path
+	}
+	return paths
+}()
+
+func collectionsOf(t reflect.Type, prefix string) map[string]reflect.Type {
+	found := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if !field.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		switch field.Type.Kind() {
+		case reflect.Struct:
+			for path, elem := range collectionsOf(field.Type, prefix+name+"/") {
+				found[path] = elem
+			}
+		case reflect.Map:
+			if embedded, ok := field.Type.Elem().FieldByName("Resource"); ok && embedded.Anonymous {
+				found[prefix+name] = field.Type.Elem()
+			}
+		}
+	}
+	return found
+}
+
+// IsCollection returns true if the path in the infrastructure configuration, e.g. ["storage", "postgres"],
+// holds resources indexed by their name.
+func IsCollection(path ...string) bool {
+	if _, found := collections[strings.Join(path, "/")]; found {
+		return true
+	}
+	_, registered := factoryOf(strings.Join(path, "/"))
+	return registered
 }
 
 func parse(arn string, path ...string) (string, string, error) {
@@ -99,7 +151,10 @@ type Resource struct {
 	Tags []string `json:"tags"`
 	// Params is how you add custom parameters to be used by the connection implementation.
 	Params Params `json:"params"`
-	err    error
+	// AliasOf is the ARN of the resource this one is an alias of, if any. Aliases keep old ARNs working after a
+	// resource is renamed and should be considered deprecated.
+	AliasOf string `json:"alias_of"`
+	err     error
 }
 
 func (r Resource) Error() error {
@@ -51,3 +51,183 @@ func TestParams(t *testing.T) {
 	assert.Equal(t, false, params.Bool("key7"))
 	assert.Equal(t, 5.0, params.Float64("key5"))
 }
+
+func TestIsCollection(t *testing.T) {
+	assert.True(t, IsCollection("cloud", "aws"))
+	assert.True(t, IsCollection("storage", "postgres"))
+	assert.True(t, IsCollection("messaging", "kafka", "clusters"))
+	assert.True(t, IsCollection("messaging", "sqs", "producers"))
+	assert.True(t, IsCollection("webservices"))
+	assert.False(t, IsCollection("storage"))
+	assert.False(t, IsCollection("messaging", "kafka"))
+	assert.False(t, IsCollection("storage", "postgres", "users"))
+}
+
+func TestLocatorAll(t *testing.T) {
+	locator := Locator{
+		Databases: Databases{
+			Postgres: map[string]Postgres{"users": {Resource: Resource{Tags: []string{"tier:1"}}, Host: "pg"}},
+		},
+		Messaging: Messaging{
+			Kafka: Kafka{Clusters: map[string]KafkaCluster{"main": {Brokers: []string{"kafka:9092"}}}},
+		},
+		Webservices: map[string]Webservice{"api": {}},
+	}
+
+	entries := locator.All()
+	if !assert.Len(t, entries, 3) {
+		t.FailNow()
+	}
+	assert.Equal(t, "arn://messaging/kafka/clusters/main", entries[0].ARN)
+	assert.Equal(t, "KafkaCluster", entries[0].Kind)
+	assert.NoError(t, entries[0].Resource.Validate())
+	assert.Equal(t, "arn://storage/postgres/users", entries[1].ARN)
+	assert.Equal(t, []string{"tier:1"}, entries[1].Tags)
+	assert.Error(t, entries[1].Resource.Validate())
+	assert.Equal(t, "arn://webservices/api", entries[2].ARN)
+
+	entry, found := locator.Lookup("arn://storage/postgres/users")
+	assert.True(t, found)
+	assert.Equal(t, "pg", entry.Resource.(Postgres).Host)
+	assert.Equal(t, uint16(5432), entry.Resource.(Postgres).Port, "resources must be located")
+	_, found = locator.Lookup("arn://storage/postgres/missing")
+	assert.False(t, found)
+}
+
+type deprecationsProvider struct {
+	Provider
+	deprecated []string
+}
+
+func (provider *deprecationsProvider) DeprecatedARN(arn, replacement string) {
+	provider.deprecated = append(provider.deprecated, arn+" "+replacement)
+}
+
+func TestLocatorAliases(t *testing.T) {
+	users := Postgres{Resource: Resource{Tags: []string{"datacenter:eu"}}, Host: "pg", Database: "users", User: "app"}
+	alias := users
+	alias.AliasOf = "arn://storage/postgres/users"
+	locator := Locator{
+		Databases: Databases{
+			Postgres: map[string]Postgres{"users": users, "old-users": alias},
+		},
+	}
+	provider := &deprecationsProvider{}
+	locator.SetProvider(provider)
+
+	entries, err := locator.Find("datacenter=eu")
+	if assert.NoError(t, err) && assert.Len(t, entries, 1, "aliases must not be listed") {
+		assert.Equal(t, "arn://storage/postgres/users", entries[0].ARN)
+	}
+	entry, found := locator.Lookup("arn://storage/postgres/old-users")
+	if assert.True(t, found) {
+		assert.Equal(t, "arn://storage/postgres/users", entry.Resource.(Postgres).AliasOf)
+	}
+	assert.Empty(t, provider.deprecated)
+
+	assert.NoError(t, Locate[Postgres](&locator, "arn://storage/postgres/users").Validate())
+	assert.Empty(t, provider.deprecated)
+	assert.NoError(t, Locate[Postgres](&locator, "arn://storage/postgres/old-users").Validate())
+	assert.Equal(t, []string{"arn://storage/postgres/old-users arn://storage/postgres/users"}, provider.deprecated)
+}
+
+func TestLocatorValidateAll(t *testing.T) {
+	locator := Locator{
+		Databases: Databases{
+			Redis: map[string]Redis{"cache": {Address: "redis:6379"}},
+		},
+		Webservices: map[string]Webservice{"api": {BaseURL: "https://api"}},
+	}
+	assert.NoError(t, locator.ValidateAll())
+
+	sftp := SFTP{Host: "sftp", User: "user"}
+	sftp.PrivateKey.Value = "key"
+	sftp.PrivateKey.Path = "/key"
+	// resources are validated as located by Locate.
+	locator.Databases.Postgres = map[string]Postgres{"users": {Host: "pg", Database: "users", User: "app"}}
+	assert.NoError(t, locator.ValidateAll())
+
+	locator.Databases.SFTP = map[string]SFTP{"both-keys": sftp}
+	locator.Webservices["broken"] = Webservice{}
+
+	err := locator.ValidateAll()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "arn://storage/sftp/both-keys: private key must be either the value or path")
+		assert.Contains(t, err.Error(), "arn://webservices/broken: empty url")
+	}
+}
+
+func TestLocate(t *testing.T) {
+	locator := Locator{
+		Databases: Databases{
+			Postgres: map[string]Postgres{"users": {Host: "pg", Database: "users", User: "app"}},
+			Redis:    map[string]Redis{"cache": {Address: "redis:6379"}},
+		},
+		Messaging: Messaging{
+			Kafka: Kafka{Clusters: map[string]KafkaCluster{"main": {Brokers: []string{"kafka:9092"}}}},
+		},
+	}
+
+	pg := Locate[Postgres](&locator, "arn://storage/postgres/users")
+	assert.NoError(t, pg.Validate())
+	assert.Equal(t, uint16(5432), pg.Port, "resources must be sanitized")
+	assert.Equal(t, pg, locator.LocatePostgresResource("arn://storage/postgres/users"))
+
+	assert.NoError(t, Locate[KafkaCluster](&locator, "arn://messaging/kafka/clusters/main").Validate())
+	assert.ErrorIs(t, Locate[Redis](&locator, "arn://storage/redis/missing").Validate(), ErrResourceNotFound)
+	assert.Error(t, Locate[Redis](&locator, "arn://storage/postgres/users").Validate())
+	assert.ErrorContains(t, Locate[Resource](&locator, "arn://storage/postgres/users").Validate(), "is not a resource type")
+}
+
+func TestPostgresGetDSN(t *testing.T) {
+	pg := Postgres{Host: "pg", Port: 5432, Database: "users", User: "app@corp", Password: "p@ss/word:+"}
+	assert.Equal(t, "postgres://app%40corp:p%40ss%2Fword%3A+@pg:5432/users", pg.GetDSN())
+	assert.Equal(t, pg.GetFullDSN(), pg.GetDSN(), "without parameters both are the same")
+}
+
+func TestLocatorFind(t *testing.T) {
+	locator := Locator{
+		Databases: Databases{
+			Postgres: map[string]Postgres{
+				"users-us": {Resource: Resource{Tags: []string{"datacenter:us-west-1", "tier:1"}}},
+				"users-eu": {Resource: Resource{Tags: []string{"datacenter:eu-west-1", "tier:1"}}},
+				"legacy":   {Resource: Resource{Tags: []string{"datacenter:us-west-1", "state:discontinued"}}},
+			},
+			Redis: map[string]Redis{
+				"cache-us": {Resource: Resource{Tags: []string{"datacenter:us-west-1", "critical"}}},
+			},
+		},
+	}
+
+	arns := func(query string, kinds ...string) []string {
+		entries, err := locator.Find(query, kinds...)
+		assert.NoError(t, err)
+		var found []string
+		for _, entry := range entries {
+			found = append(found, entry.ARN)
+		}
+		return found
+	}
+
+	assert.Equal(t, []string{
+		"arn://storage/postgres/users-us",
+		"arn://storage/redis/cache-us",
+	}, arns("datacenter=us-west-1,!state=discontinued"))
+	assert.Equal(t, []string{"arn://storage/postgres/users-us"}, arns("datacenter=us-west-1, !state=discontinued", "postgres"))
+	assert.Equal(t, []string{"arn://storage/postgres/legacy"}, arns("state"))
+	assert.Equal(t, []string{"arn://storage/redis/cache-us"}, arns("critical"))
+	assert.Equal(t, []string{"arn://storage/postgres/users-eu", "arn://storage/postgres/users-us"}, arns("tier=1"))
+	assert.Len(t, arns(""), 4)
+	assert.Len(t, arns("", "Redis"), 1)
+	assert.Empty(t, arns("datacenter=ap-south-1"))
+
+	entries, err := locator.Find("tier=1", "Postgres")
+	if assert.NoError(t, err) && assert.Len(t, entries, 2) {
+		assert.Equal(t, uint16(5432), entries[0].Resource.(Postgres).Port, "resources must be located")
+	}
+
+	_, err = locator.Find("datacenter=us-west-1,,tier=1")
+	assert.Error(t, err)
+	_, err = locator.Find("!=x")
+	assert.Error(t, err)
+}
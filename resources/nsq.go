@@ -38,18 +38,7 @@ func (r NSQProducer) sanitize() NSQProducer {
 
 // LocateNSQProducerResource ...
 func (irl *Locator) LocateNSQProducerResource(arn string) NSQProducer {
-	var resource NSQProducer
-	var found bool
-	var name, _, err = parse(arn, "messaging", "nsq", "producers")
-	if err != nil {
-		resource.Resource.err = err
-		return resource
-	}
-	if resource, found = irl.Messaging.NSQ.Producers[name]; !found {
-		resource.Resource.err = ErrResourceNotFound
-		return resource
-	}
-	return resource.sanitize()
+	return Locate[NSQProducer](irl, arn)
 }
 
 // NSQConsumer resource configuration datastructure.
@@ -90,16 +79,5 @@ func (resource NSQConsumer) sanitize() NSQConsumer {
 
 // LocateNSQConsumerResource ...
 func (irl *Locator) LocateNSQConsumerResource(arn string) NSQConsumer {
-	var resource NSQConsumer
-	var found bool
-	var name, _, err = parse(arn, "messaging", "nsq", "consumers")
-	if err != nil {
-		resource.Resource.err = err
-		return resource
-	}
-	if resource, found = irl.Messaging.NSQ.Consumers[name]; !found {
-		resource.Resource.err = ErrResourceNotFound
-		return resource
-	}
-	return resource.sanitize()
+	return Locate[NSQConsumer](irl, arn)
 }
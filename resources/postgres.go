@@ -2,7 +2,11 @@ package resources
 
 import (
 	"fmt"
+	"net"
 	"net/url"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 // Postgres configuration datastructure.
@@ -15,10 +19,57 @@ type Postgres struct {
 	Password string `json:"password"`
 	// DSNParams are extra connection parameters to be appended to the DSN in the format of key=value.
 	DSNParams map[string]string `json:"dsn_params"`
+	// Roles with their own credentials, located with the role in the ARN, e.g. `arn://storage/postgres/users/readonly`.
+	Roles map[string]PostgresRole `json:"roles"`
+	// Hosts are additional hosts, in the format `host[:port]`, of the primary for libpq multi-host failover.
+	// The DSN of the writer lists Host followed by these, with `target_session_attrs=read-write`.
+	Hosts []string `json:"hosts"`
+	// Replicas for read only connections.
+	Replicas []PostgresReplica `json:"replicas"`
+	// TLS settings, added to the parameters of every DSN and replacing any of the same name in DSNParams.
+	TLS PostgresTLS `json:"tls"`
+}
+
+// PostgresTLS settings of a Postgres resource.
+type PostgresTLS struct {
+	// Mode is the libpq sslmode: disable, allow, prefer, require, verify-ca or verify-full.
+	// Defaults to the sslmode in DSNParams, if any, or to the default of the driver.
+	Mode string `json:"mode"`
+	// CAFile with the certificates of the authorities trusted to sign the certificate of the server.
+	// Defaults to the certificates of the Provider, see ProviderSettings.CertFolders.
+	CAFile string `json:"ca_file"`
+	// CertFile with the client certificate, if the server requires one.
+	CertFile string `json:"cert_file"`
+	// KeyFile with the key of the client certificate.
+	KeyFile string `json:"key_file"`
+}
+
+var postgresSSLModes = map[string]bool{
+	"disable":     true,
+	"allow":       true,
+	"prefer":      true,
+	"require":     true,
+	"verify-ca":   true,
+	"verify-full": true,
+}
+
+// PostgresReplica is a read replica of a Postgres resource, sharing its database and credentials.
+type PostgresReplica struct {
+	Host string `json:"host"`
+	// Port defaults to the port of the resource.
+	Port uint16 `json:"port"`
+	// Tags of the replica, such as `az:us-east-1b` for the availability zone it runs in.
+	Tags []string `json:"tags"`
+}
+
+// PostgresRole holds the credentials of a role which replace the ones of the resource.
+type PostgresRole struct {
+	User     string `json:"user"`
+	Password string `json:"password"`
 }
 
 // Validate returns an error if the resource is invalid.
-func (r *Postgres) Validate() error {
+func (r Postgres) Validate() error {
 	if r.err != nil {
 		return r.err
 	}
@@ -31,29 +82,177 @@ func (r *Postgres) Validate() error {
 	if r.User == "" {
 		return fmt.Errorf("postgres user configuration undefined")
 	}
+	for i, replica := range r.Replicas {
+		if replica.Host == "" {
+			return fmt.Errorf("postgres replica %d host configuration undefined", i)
+		}
+	}
+	if r.TLS.Mode != "" && !postgresSSLModes[r.TLS.Mode] {
+		return fmt.Errorf("postgres tls mode %s is invalid", r.TLS.Mode)
+	}
+	if (r.TLS.CertFile == "") != (r.TLS.KeyFile == "") {
+		return fmt.Errorf("postgres tls requires both cert_file and key_file")
+	}
 	return r.err
 }
 
-// GetDSN for this configuration. Returns an empty string if configuration is incomplete.
+// GetDSN for this configuration, without connection parameters. The user and password are escaped.
 func (cfg Postgres) GetDSN() string {
-	return fmt.Sprintf("postgres://%s:%s@%s:%d/%s", cfg.User, url.QueryEscape(cfg.Password), cfg.Host, cfg.Port, cfg.Database)
+	return cfg.dsn([]string{net.JoinHostPort(cfg.Host, strconv.Itoa(int(cfg.Port)))}, nil)
 }
 
-// GetFullDSN which includes extra connection parameters in the format <dsn>?<key>=<value>. Returns an empty string if configuration is incomplete.
+// GetFullDSN which includes extra connection parameters, and the TLS settings, in the format <dsn>?<key>=<value>.
+// Parameters are escaped and sorted by name.
 func (cfg Postgres) GetFullDSN() string {
-	if len(cfg.Params) == 0 {
-		return cfg.GetDSN()
+	return cfg.dsn([]string{net.JoinHostPort(cfg.Host, strconv.Itoa(int(cfg.Port)))}, cfg.params())
+}
+
+// SSLMode in use, as set by the TLS settings or DSNParams. Empty if neither sets it.
+func (cfg Postgres) SSLMode() string {
+	if cfg.TLS.Mode != "" {
+		return cfg.TLS.Mode
 	}
+	return cfg.DSNParams["sslmode"]
+}
 
-	var extra string
-	for k, v := range cfg.DSNParams {
-		if extra != "" {
-			extra += "&"
+// KeyValueDSN for connecting to the primary, the same as WriterDSN but in the key/value format, e.g.
+// `host=localhost port=5432 dbname=users user=app password='p@ss word' sslmode=verify-full`.
+// Values are quoted when needed and parameters are sorted by name.
+func (cfg Postgres) KeyValueDSN() string {
+	hosts := []string{cfg.Host}
+	ports := []string{strconv.Itoa(int(cfg.Port))}
+	for _, host := range cfg.Hosts {
+		port := strconv.Itoa(int(cfg.Port))
+		if h, p, err := net.SplitHostPort(host); err == nil {
+			host, port = h, p
 		}
-		extra += fmt.Sprintf("%s=%s", k, v)
+		hosts = append(hosts, host)
+		ports = append(ports, port)
 	}
+	return cfg.keyValueDSN(hosts, ports, cfg.writerParams(len(hosts)))
+}
+
+// WriterDSN for connecting to the primary, with DSNParams. If the resource has more than one host the DSN lists all of
+// them and, unless set in DSNParams, has `target_session_attrs=read-write` for libpq to connect to the primary.
+func (cfg Postgres) WriterDSN() string {
+	hosts := []string{net.JoinHostPort(cfg.Host, strconv.Itoa(int(cfg.Port)))}
+	for _, host := range cfg.Hosts {
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			host = net.JoinHostPort(host, strconv.Itoa(int(cfg.Port)))
+		}
+		hosts = append(hosts, host)
+	}
+	return cfg.dsn(hosts, cfg.writerParams(len(hosts)))
+}
 
-	return fmt.Sprintf("%s?%s", cfg.GetDSN(), extra)
+// ReaderDSNs for connecting to each of the replicas, with DSNParams, in the order they are configured.
+// Returns the DSN of the writer if the resource has no replicas.
+func (cfg Postgres) ReaderDSNs() []string {
+	if len(cfg.Replicas) == 0 {
+		return []string{cfg.WriterDSN()}
+	}
+	dsns := make([]string, 0, len(cfg.Replicas))
+	for _, replica := range cfg.Replicas {
+		port := replica.Port
+		if port == 0 {
+			port = cfg.Port
+		}
+		dsns = append(dsns, cfg.dsn([]string{net.JoinHostPort(replica.Host, strconv.Itoa(int(port)))}, cfg.params()))
+	}
+	return dsns
+}
+
+// ReaderKeyValueDSNs for connecting to each of the replicas, the same as ReaderDSNs but in the key/value format.
+// Returns the KeyValueDSN of the writer if the resource has no replicas.
+func (cfg Postgres) ReaderKeyValueDSNs() []string {
+	if len(cfg.Replicas) == 0 {
+		return []string{cfg.KeyValueDSN()}
+	}
+	dsns := make([]string, 0, len(cfg.Replicas))
+	for _, replica := range cfg.Replicas {
+		port := replica.Port
+		if port == 0 {
+			port = cfg.Port
+		}
+		dsns = append(dsns, cfg.keyValueDSN([]string{replica.Host}, []string{strconv.Itoa(int(port))}, cfg.params()))
+	}
+	return dsns
+}
+
+// params of the DSN, which are the DSNParams with the TLS settings.
+func (cfg Postgres) params() map[string]string {
+	params := make(map[string]string, len(cfg.DSNParams)+4)
+	for key, value := range cfg.DSNParams {
+		params[key] = value
+	}
+	for key, value := range map[string]string{
+		"sslmode":     cfg.TLS.Mode,
+		"sslrootcert": cfg.TLS.CAFile,
+		"sslcert":     cfg.TLS.CertFile,
+		"sslkey":      cfg.TLS.KeyFile,
+	} {
+		if value != "" {
+			params[key] = value
+		}
+	}
+	return params
+}
+
+// writerParams are the params of the DSN for connecting to the primary through any of the hosts.
+func (cfg Postgres) writerParams(hosts int) map[string]string {
+	params := cfg.params()
+	if _, found := params["target_session_attrs"]; !found && hosts > 1 {
+		params["target_session_attrs"] = "read-write"
+	}
+	return params
+}
+
+func sortedParams(params map[string]string) []string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// quoteDSNValue for the key/value format, quoting empty values and values with spaces, quotes or backslashes.
+func quoteDSNValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " '\\\t\n") {
+		return value
+	}
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(value) + "'"
+}
+
+// keyValueDSN for the hosts and their ports, with the parameters sorted by name.
+func (cfg Postgres) keyValueDSN(hosts []string, ports []string, params map[string]string) string {
+	pairs := []string{
+		"host=" + quoteDSNValue(strings.Join(hosts, ",")),
+		"port=" + quoteDSNValue(strings.Join(ports, ",")),
+		"dbname=" + quoteDSNValue(cfg.Database),
+		"user=" + quoteDSNValue(cfg.User),
+		"password=" + quoteDSNValue(cfg.Password),
+	}
+	for _, key := range sortedParams(params) {
+		pairs = append(pairs, key+"="+quoteDSNValue(params[key]))
+	}
+	return strings.Join(pairs, " ")
+}
+
+// dsn for the hosts, in the format `host:port`, with the parameters sorted by name.
+func (cfg Postgres) dsn(hosts []string, params map[string]string) string {
+	query := make(url.Values, len(params))
+	for key, value := range params {
+		query.Set(key, value)
+	}
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     strings.Join(hosts, ","),
+		Path:     "/" + cfg.Database,
+		RawQuery: query.Encode(),
+	}
+	return dsn.String()
 }
 
 func (r Postgres) sanitize() Postgres {
@@ -64,18 +263,23 @@ func (r Postgres) sanitize() Postgres {
 	return r
 }
 
-// LocatePostgresResource ...
+// withRole returns the resource with the credentials of the role.
+func (r Postgres) withRole(name string) (Postgres, error) {
+	role, found := r.Roles[name]
+	if !found {
+		return r, fmt.Errorf("postgres role %s not defined", name)
+	}
+	if role.User != "" {
+		r.User = role.User
+	}
+	if role.Password != "" {
+		r.Password = role.Password
+	}
+	return r, nil
+}
+
+// LocatePostgresResource pointed to by the ARN. If the ARN has a role, e.g. `arn://storage/postgres/users/readonly`,
+// the credentials of the role replace the ones of the resource.
 func (irl Locator) LocatePostgresResource(arn string) Postgres {
-	var resource Postgres
-	var found bool
-	var name, _, err = parse(arn, "storage", "postgres")
-	if err != nil {
-		resource.Resource.err = err
-		return resource
-	}
-	if resource, found = irl.Databases.Postgres[name]; !found {
-		resource.Resource.err = ErrResourceNotFound
-		return resource
-	}
-	return resource.sanitize()
+	return Locate[Postgres](&irl, arn)
 }
@@ -32,16 +32,5 @@ func (r Redis) sanitize() Redis {
 
 // LocateRedisResource ...
 func (irl Locator) LocateRedisResource(arn string) Redis {
-	var resource Redis
-	var found bool
-	var name, _, err = parse(arn, "storage", "redis")
-	if err != nil {
-		resource.Resource.err = err
-		return resource
-	}
-	if resource, found = irl.Databases.Redis[name]; !found {
-		resource.Resource.err = ErrResourceNotFound
-		return resource
-	}
-	return resource.sanitize()
+	return Locate[Redis](&irl, arn)
 }
@@ -0,0 +1,185 @@
+package resources
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Registrable is implemented by pointers to structs embedding Resource, such as:
+//
+//	type MongoDB struct {
+//		resources.Resource
+//		URI string `json:"uri"`
+//	}
+//
+//	func (r *MongoDB) Validate() error {
+//		if err := r.Resource.Validate(); err != nil {
+//			return err
+//		}
+//		if r.URI == "" {
+//			return fmt.Errorf("mongodb uri can not be empty")
+//		}
+//		return nil
+//	}
+type Registrable interface {
+	Validator
+	base() *Resource
+}
+
+func (r *Resource) base() *Resource {
+	return r
+}
+
+// Factory creates a new resource of a registered type, e.g. `func() resources.Registrable { return &MongoDB{} }`.
+type Factory func() Registrable
+
+var registry = struct {
+	sync.RWMutex
+	factories map[string]Factory
+}{factories: make(map[string]Factory)}
+
+// RegisterType of resource found at the path of the infrastructure configuration, e.g. "storage/mongodb".
+// Resources of the type are located with ARNs such as `arn://storage/mongodb/<name>` and are decoded from the
+// configuration using their json tags, just like built-in resources.
+// Types are meant to be registered when initializing a package. It panics if the path is already in use.
+func RegisterType(path string, factory Factory) {
+	path = strings.Trim(path, "/")
+	if path == "" || factory == nil {
+		panic("resources: RegisterType requires a path and a factory")
+	}
+	if _, found := collections[path]; found || pathOf(reflect.TypeOf(Locator{}), strings.Split(path, "/")) {
+		panic(fmt.Sprintf("resources: path %s is already in use by the Locator", path))
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+	if _, found := registry.factories[path]; found {
+		panic(fmt.Sprintf("resources: type already registered for %s", path))
+	}
+	registry.factories[path] = factory
+}
+
+// pathOf returns true if the path, or a part of it, is a field of the Locator which is not a group of resources.
+func pathOf(t reflect.Type, path []string) bool {
+	for i, name := range path {
+		if t.Kind() != reflect.Struct {
+			return i > 0
+		}
+		field, found := fieldTypeByTag(t, name)
+		if !found {
+			return false
+		}
+		t = field
+	}
+	return true
+}
+
+func fieldTypeByTag(t reflect.Type, name string) (reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if tag, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ","); tag == name {
+			return t.Field(i).Type, true
+		}
+	}
+	return nil, false
+}
+
+func factoryOf(path string) (Factory, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	factory, found := registry.factories[path]
+	return factory, found
+}
+
+// registeredPathOf the type, which is the pointer type returned by the factory.
+func registeredPathOf(t reflect.Type) (string, bool) {
+	registry.RLock()
+	defer registry.RUnlock()
+	for path, factory := range registry.factories {
+		if reflect.TypeOf(factory()) == t {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+func registeredPaths() []string {
+	registry.RLock()
+	defer registry.RUnlock()
+	paths := make([]string, 0, len(registry.factories))
+	for path := range registry.factories {
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// LocateRegistered resource pointed to by the ARN, which must be of a type registered with RegisterType.
+// Errors locating the resource are returned by its Validate method. Locating an alias is reported just like Locate does.
+func (irl *Locator) LocateRegistered(arn string) Registrable {
+	return irl.locateRegistered(arn, true)
+}
+
+func (irl *Locator) locateRegistered(arn string, report bool) Registrable {
+	parsed, err := Parse(arn)
+	if err != nil {
+		return &Resource{err: err}
+	}
+	path := strings.Join(append([]string{parsed.Group()}, parsed.Subgroups()...), "/")
+	factory, found := factoryOf(path)
+	if !found {
+		return &Resource{err: fmt.Errorf("no resource type registered for %s", path)}
+	}
+
+	raw, found := irl.section(path)[parsed.Name()]
+	if !found {
+		resource := factory()
+		resource.base().err = ErrResourceNotFound
+		return resource
+	}
+	resource := decodeRegistered(factory, raw)
+	if report {
+		irl.reportAlias(arn, *resource.base())
+	}
+	return resource
+}
+
+// decodeRegistered creates a resource with the factory from its raw configuration.
+func decodeRegistered(factory Factory, raw interface{}) Registrable {
+	resource := factory()
+	// the raw configuration goes through JSON so that the resource is decoded using its json tags.
+	encoded, err := json.Marshal(raw)
+	if err == nil {
+		err = json.Unmarshal(encoded, resource)
+	}
+	if err != nil {
+		resource.base().err = fmt.Errorf("failed to decode resource; %w", err)
+		return resource
+	}
+	resource.base().err = resource.Validate()
+	return resource
+}
+
+// section of the infrastructure configuration at the path which is not part of the Locator structure.
+func (irl *Locator) section(path string) map[string]interface{} {
+	value := reflect.ValueOf(irl).Elem()
+	var raw map[string]interface{}
+	for _, name := range strings.Split(path, "/") {
+		switch {
+		case raw != nil:
+			raw, _ = raw[name].(map[string]interface{})
+		case value.Kind() == reflect.Struct:
+			if field := fieldByTag(value, name); field.IsValid() {
+				value = field
+				continue
+			}
+			extra, _ := value.FieldByName("Extra").Interface().(map[string]interface{})
+			raw, _ = extra[name].(map[string]interface{})
+		}
+		if raw == nil {
+			return nil
+		}
+	}
+	return raw
+}
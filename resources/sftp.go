@@ -52,16 +52,5 @@ func (r SFTP) sanitize() SFTP {
 
 // LocateSFTPResource pointed to by the ARN.
 func (irl Locator) LocateSFTPResource(arn string) SFTP {
-	var resource SFTP
-	var found bool
-	var name, _, err = parse(arn, "storage", "sftp")
-	if err != nil {
-		resource.Resource.err = err
-		return resource
-	}
-	if resource, found = irl.Databases.SFTP[name]; !found {
-		resource.Resource.err = ErrResourceNotFound
-		return resource
-	}
-	return resource.sanitize()
+	return Locate[SFTP](&irl, arn)
 }
@@ -1,16 +1,51 @@
 package resources
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
+
+// Authorisation types of webservices.
+const (
+	// AuthBearer sends the key as a bearer token in the Authorization header.
+	AuthBearer = "bearer"
+	// AuthBasic sends the key, in the format `user:password`, as basic credentials in the Authorization header.
+	AuthBasic = "basic"
+	// AuthAPIKey sends the key in a header or a query parameter.
+	AuthAPIKey = "api-key"
+	// AuthOAuth2 sends a bearer token obtained through the OAuth2 client credentials grant.
+	AuthOAuth2 = "oauth2"
+)
 
 // Webservice resource configuration datastructure.
 type Webservice struct {
 	Resource
-	BaseURL       string            `json:"url"`
-	Headers       map[string]string `json:"headers"`
-	Authorisation struct {
-		Type string `json:"type"`
-		Key  string `json:"key"`
-	} `json:"authorisation"`
+	BaseURL       string                  `json:"url"`
+	Headers       map[string]string       `json:"headers"`
+	Authorisation WebserviceAuthorisation `json:"authorisation"`
+}
+
+// WebserviceAuthorisation of the requests to a webservice.
+type WebserviceAuthorisation struct {
+	// Type is AuthBearer, AuthBasic, AuthAPIKey or AuthOAuth2, in any case. Requests are not authorised when empty.
+	Type string `json:"type"`
+	Key  string `json:"key"`
+	// Header with the key when the type is AuthAPIKey. Defaults to `X-API-Key` unless Query is set.
+	Header string `json:"header"`
+	// Query parameter with the key when the type is AuthAPIKey.
+	Query string `json:"query"`
+	// OAuth2 client credentials when the type is AuthOAuth2.
+	OAuth2 OAuth2Credentials `json:"oauth2"`
+}
+
+// OAuth2Credentials for obtaining tokens through the OAuth2 client credentials grant.
+type OAuth2Credentials struct {
+	TokenURL     string   `json:"token_url"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	Scopes       []string `json:"scopes"`
+	// Audience of the tokens, for authorisation servers requiring one.
+	Audience string `json:"audience"`
 }
 
 // Validate resource.
@@ -21,6 +56,20 @@ func (r Webservice) Validate() error {
 	if r.BaseURL == "" {
 		return fmt.Errorf("empty url")
 	}
+	// types are case insensitive and the ones unknown are left for clients to reject.
+	switch strings.ToLower(r.Authorisation.Type) {
+	case AuthBearer, AuthBasic, AuthAPIKey:
+		if r.Authorisation.Key == "" {
+			return fmt.Errorf("empty %s authorisation key", r.Authorisation.Type)
+		}
+	case AuthOAuth2:
+		if r.Authorisation.OAuth2.TokenURL == "" {
+			return fmt.Errorf("empty oauth2 token url")
+		}
+		if r.Authorisation.OAuth2.ClientID == "" || r.Authorisation.OAuth2.ClientSecret == "" {
+			return fmt.Errorf("empty oauth2 client credentials")
+		}
+	}
 	return r.err
 }
 
@@ -31,16 +80,5 @@ func (r Webservice) sanitize() Webservice {
 
 // LocateWebserviceResource pointed to by the ARN.
 func (irl Locator) LocateWebserviceResource(arn string) Webservice {
-	var resource Webservice
-	var found bool
-	var name, _, err = parse(arn, "webservices")
-	if err != nil {
-		resource.Resource.err = err
-		return resource
-	}
-	if resource, found = irl.Webservices[name]; !found {
-		resource.Resource.err = ErrResourceNotFound
-		return resource
-	}
-	return resource.sanitize()
+	return Locate[Webservice](&irl, arn)
 }
@@ -0,0 +1,12 @@
+package infrastructure
+
+// SecretSource provides secrets to configuration templates through the `secret` template function.
+// Check package lib/secrets for the built-in sources.
+type SecretSource interface {
+	// Secret returns the value for the key. Keys in the format `<path>#<field>` reference a field of a secret.
+	Secret(key string) (string, error)
+}
+
+// FileSecretSource is the name of the source used by the `file` template function.
+// Unless another source is registered with this name it reads local files (secrets.Files).
+const FileSecretSource = "file"
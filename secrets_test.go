@@ -0,0 +1,70 @@
+package infrastructure
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vredens/infrastructure/lib/secrets"
+)
+
+type countingSource struct {
+	values map[string]string
+	calls  int
+}
+
+func (src *countingSource) Secret(key string) (string, error) {
+	src.calls++
+	if value, found := src.values[key]; found {
+		return value, nil
+	}
+	return "", fmt.Errorf("secret %s not found", key)
+}
+
+func TestRenderSecretSources(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "pg"), "pg-pass\n")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"password": "kv-pass"}`))
+	}))
+	defer server.Close()
+
+	vault := &countingSource{values: map[string]string{"db/users#password": "vault-pass"}}
+	provider, err := NewProvider(ProviderSettings{
+		EnvName:       "test",
+		SystemName:    "sys",
+		ComponentName: "cmp",
+		SecretSources: map[string]SecretSource{
+			"vault":  vault,
+			"docker": secrets.NewDirectory(dir),
+			"kv":     secrets.HTTP{URL: server.URL},
+		},
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	result, err := provider.RenderSecrets(`{{ secret "vault" "db/users#password" }}:{{ secret "vault" "db/users#password" }}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "vault-pass:vault-pass", result)
+	assert.Equal(t, 1, vault.calls)
+
+	result, err = provider.RenderSecrets(`{{ secret "vault" "db/users#password" }}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "vault-pass", result)
+	assert.Equal(t, 2, vault.calls)
+
+	result, err = provider.RenderSecrets(`{{ secret "docker" "pg" }}|{{ file "` + filepath.Join(dir, "pg") + `" }}|{{ secret "kv" "db/users#password" }}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "pg-pass|pg-pass|kv-pass", result)
+
+	_, err = provider.RenderSecrets(`{{ secret "vault" "missing" }}`)
+	assert.Error(t, err)
+
+	_, err = provider.RenderSecrets(`{{ secret "unknown" "key" }}`)
+	assert.Error(t, err)
+}
@@ -0,0 +1,238 @@
+package infrastructure
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// RenderSecrets using provider replaceVariables in the given config file
+// it will return an error when the config file has invalid placeholerds, such as
+// nonexisting functions (e.g. {{ test }}) or invalid properties (e.g. {{ .test }}).
+// With ProviderSettings.StrictTemplates it also returns an error listing every unresolved reference,
+// such as a missing environment variable (e.g. {{ .Env.MISSING }}), along with its line number.
+func (provider *Provider) RenderSecrets(value string) (empty string, err error) {
+	t := template.New("secrets").Funcs(provider.templateFuncs())
+	if provider.settings.StrictTemplates {
+		t = t.Option("missingkey=error")
+	}
+	t, err = t.Parse(value)
+	if err != nil {
+		return empty, fmt.Errorf("failed create template from config file; %w", err)
+	}
+
+	if provider.settings.StrictTemplates {
+		if err := checkReferences(t, provider.data); err != nil {
+			return empty, fmt.Errorf("failed render template; %w", err)
+		}
+	}
+
+	tmp, err := renderTemplate(t, provider.data)
+	if err != nil {
+		return empty, fmt.Errorf("failed render template; %w", err)
+	}
+	return string(tmp), nil
+}
+
+// RenderSecrets using provider replaceVariables in the given config file returning
+// given value if doesn't exist or is an invalid template function.
+// With ProviderSettings.StrictTemplates an empty string is returned instead of the given value.
+func (provider *Provider) RenderSecret(value string) string {
+	tmp, err := provider.RenderSecrets(value)
+	if err != nil {
+		if provider.settings.StrictTemplates {
+			return ""
+		}
+		return value
+	}
+	return tmp
+}
+
+func renderTemplate(tmpl *template.Template, data interface{}) ([]byte, error) {
+	// TODO: this should be a buffer pool and 2048 is based on the amazing science of wild guess.
+	buf := bytes.NewBuffer(make([]byte, 0, 2048))
+
+	if err := tmpl.Execute(buf, data); err != nil {
+		return nil, fmt.Errorf("failed to execute template; %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// templateFuncs returns the functions available when rendering configuration templates.
+// Secrets are cached for the lifetime of the returned functions so that each render fetches a secret only once.
+func (provider *Provider) templateFuncs() template.FuncMap {
+	cache := make(map[[2]string]string)
+	secret := func(name string, key string) (string, error) {
+		if value, found := cache[[2]string{name, key}]; found {
+			return value, nil
+		}
+		source, found := provider.settings.SecretSources[name]
+		if !found {
+			return "", fmt.Errorf("unknown secret source %s", name)
+		}
+		value, err := source.Secret(key)
+		if err != nil {
+			return "", fmt.Errorf("failed to get secret %s from %s; %w", key, name, err)
+		}
+		cache[[2]string{name, key}] = value
+		return value, nil
+	}
+
+	return template.FuncMap{
+		"secret": secret,
+		"file": func(path string) (string, error) {
+			return secret(FileSecretSource, path)
+		},
+		"env": func(name string) string {
+			return provider.data.Env[name]
+		},
+		"default":  tmplDefault,
+		"required": tmplRequired,
+		"b64dec":   tmplB64Dec,
+		"toJson":   tmplToJSON,
+		"trim":     strings.TrimSpace,
+		"lower":    strings.ToLower,
+		"upper":    strings.ToUpper,
+	}
+}
+
+// tmplDefault returns value unless it is empty, in which case def is returned.
+// Usage: {{ env "PORT" | default "5432" }}.
+func tmplDefault(def interface{}, value interface{}) interface{} {
+	if isEmpty(value) {
+		return def
+	}
+	return value
+}
+
+// tmplRequired fails rendering with the message if value is empty.
+// Usage: {{ env "DB_HOST" | required "DB_HOST is mandatory" }}.
+func tmplRequired(msg string, value interface{}) (interface{}, error) {
+	if isEmpty(value) {
+		return nil, errors.New(msg)
+	}
+	return value, nil
+}
+
+func tmplB64Dec(value string) (string, error) {
+	decoded, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode base64 value; %w", err)
+	}
+	return string(decoded), nil
+}
+
+// tmplToJSON encodes the value as JSON, which is the safest way to place a secret inside a JSON string.
+// Usage: "password": {{ secret "vault" "db#password" | toJson }}.
+func tmplToJSON(value interface{}) (string, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode value as JSON; %w", err)
+	}
+	return string(encoded), nil
+}
+
+func isEmpty(value interface{}) bool {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	default:
+		return v.IsZero()
+	}
+}
+
+// checkReferences walks the template looking for field references (e.g. .Env.NAME) which can not be resolved against
+// the data and returns an error for each one of them. References inside `range` and `with` blocks are skipped since
+// they are relative to a different value.
+func checkReferences(tmpl *template.Template, data interface{}) error {
+	if tmpl.Tree == nil || tmpl.Root == nil {
+		return nil
+	}
+	var errs []error
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch n := node.(type) {
+		case *parse.ListNode:
+			if n == nil {
+				return
+			}
+			for _, child := range n.Nodes {
+				walk(child)
+			}
+		case *parse.ActionNode:
+			walk(n.Pipe)
+		case *parse.IfNode:
+			walk(n.Pipe)
+			walk(n.List)
+			walk(n.ElseList)
+		case *parse.RangeNode:
+			walk(n.Pipe)
+		case *parse.WithNode:
+			walk(n.Pipe)
+		case *parse.TemplateNode:
+			walk(n.Pipe)
+		case *parse.PipeNode:
+			if n == nil {
+				return
+			}
+			for _, cmd := range n.Cmds {
+				walk(cmd)
+			}
+		case *parse.CommandNode:
+			for _, arg := range n.Args {
+				walk(arg)
+			}
+		case *parse.FieldNode:
+			if !resolves(reflect.ValueOf(data), n.Ident) {
+				errs = append(errs, fmt.Errorf("line %s: unresolved reference %s", lineOf(tmpl, n), n.String()))
+			}
+		}
+	}
+	walk(tmpl.Root)
+	return errors.Join(errs...)
+}
+
+// lineOf the node in the template source.
+func lineOf(tmpl *template.Template, node parse.Node) string {
+	// location is in the format <template name>:<line>:<column>
+	location, _ := tmpl.Tree.ErrorContext(node)
+	parts := strings.Split(location, ":")
+	if len(parts) < 3 {
+		return "?"
+	}
+	return parts[len(parts)-2]
+}
+
+// resolves checks that the chain of field names or map keys exists in the value.
+func resolves(value reflect.Value, idents []string) bool {
+	for _, ident := range idents {
+		for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+			value = value.Elem()
+		}
+		switch value.Kind() {
+		case reflect.Struct:
+			value = value.FieldByName(ident)
+		case reflect.Map:
+			value = value.MapIndex(reflect.ValueOf(ident))
+		default:
+			return false
+		}
+		if !value.IsValid() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,98 @@
+package infrastructure
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderStrictTemplates(t *testing.T) {
+	os.Setenv("INFRA_TEST_VAR", "test_value")
+	infraDir := t.TempDir()
+	writeFile(t, filepath.Join(infraDir, "test.json"), `{"webservices": {"api": {"url": "https://{{ .Environment }}.example.com"}}}`)
+	provider, err := NewProvider(ProviderSettings{
+		EnvName:            "test",
+		SystemName:         "system",
+		ComponentName:      "comp",
+		InfraConfigFolders: []string{infraDir},
+		StrictTemplates:    true,
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	result, err := provider.RenderSecrets("{{ .Env.INFRA_TEST_VAR }}")
+	assert.NoError(t, err)
+	assert.Equal(t, "test_value", result)
+
+	_, err = provider.RenderSecrets("{\n\t\"a\": \"{{ .Env.INFRA_MISSING_1 }}\",\n\t\"b\": \"{{ .Environment }}\",\n\t\"c\": \"{{ .Env.INFRA_MISSING_2 }}\"\n}")
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "line 2: unresolved reference .Env.INFRA_MISSING_1")
+		assert.Contains(t, err.Error(), "line 4: unresolved reference .Env.INFRA_MISSING_2")
+	}
+
+	_, err = provider.RenderSecrets(`{{ if .Env.INFRA_MISSING_3 }}x{{ end }}`)
+	assert.Error(t, err)
+
+	result, err = provider.RenderSecrets(`{{ env "INFRA_MISSING_1" | default "fallback" }}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "fallback", result)
+
+	assert.Equal(t, "", provider.RenderSecret("{{ .Env.INFRA_MISSING_1 }}"))
+	assert.Equal(t, "system", provider.RenderSecret("{{ .System }}"))
+
+	lenient, err := NewProvider(ProviderSettings{
+		EnvName:       "test",
+		SystemName:    "system",
+		ComponentName: "comp",
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	_, err = lenient.RenderSecrets("{{ .Env.INFRA_MISSING_1 }}")
+	assert.NoError(t, err)
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	os.Setenv("INFRA_TEST_VAR", "test_value")
+	os.Setenv("INFRA_TEST_B64", "c2VjcmV0")
+	provider, err := NewProvider(ProviderSettings{
+		EnvName:       "test",
+		SystemName:    "system",
+		ComponentName: "comp",
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	testCases := []struct {
+		template string
+		expected string
+	}{
+		{template: `{{ env "INFRA_TEST_VAR" }}`, expected: "test_value"},
+		{template: `{{ env "INFRA_MISSING" | default "5432" }}`, expected: "5432"},
+		{template: `{{ .Env.INFRA_TEST_VAR | default "other" }}`, expected: "test_value"},
+		{template: `{{ .Env.INFRA_MISSING | default "other" }}`, expected: "other"},
+		{template: `{{ env "INFRA_TEST_VAR" | required "missing INFRA_TEST_VAR" }}`, expected: "test_value"},
+		{template: `{{ env "INFRA_TEST_B64" | b64dec }}`, expected: "secret"},
+		{template: `{{ "  padded " | trim }}`, expected: "padded"},
+		{template: `{{ "MiXeD" | lower }}-{{ "MiXeD" | upper }}`, expected: "mixed-MIXED"},
+		{template: `{{ "quote\"d" | toJson }}`, expected: `"quote\"d"`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.template, func(t *testing.T) {
+			result, err := provider.RenderSecrets(tc.template)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+
+	_, err = provider.RenderSecrets(`{{ env "INFRA_MISSING" | required "INFRA_MISSING is mandatory" }}`)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "INFRA_MISSING is mandatory")
+	}
+	_, err = provider.RenderSecrets(`{{ "not base64!" | b64dec }}`)
+	assert.Error(t, err)
+}
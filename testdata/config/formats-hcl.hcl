@@ -0,0 +1,2 @@
+format = "hcl"
+hosts = ["a", "b"]
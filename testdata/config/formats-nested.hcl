@@ -0,0 +1,11 @@
+format = "hcl"
+hosts = ["a", "b"]
+
+repo {
+  arn = "arn://storage/postgres/users"
+
+  params {
+    max_idle_conns = 4
+    max_open_conns = 16
+  }
+}
@@ -0,0 +1,241 @@
+package infrastructure
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long to wait for a burst of file system events to settle before checking for changes.
+const watchDebounce = 100 * time.Millisecond
+
+// RegisterCallback for notification when a configuration changes. Callbacks are not notified of configurations which
+// fail to load, see RegisterErrorCallback.
+func (provider *Provider) RegisterCallback(fn func()) {
+	provider.RegisterErrorCallback(func(err error) {
+		if err == nil {
+			fn()
+		}
+	})
+}
+
+// RegisterErrorCallback for notification when a configuration changes or fails to load.
+//
+// The first call starts watching the infrastructure configuration file and every application configuration file
+// loaded through LoadConfig. When the infrastructure configuration changes it is rendered and unmarshalled into a
+// new Locator which replaces the current one before callbacks are notified. If the new configuration can not be
+// loaded the current Locator is kept and the error is passed on to the callbacks.
+// When only application configuration files change the callbacks are notified with a nil error and it is up to the
+// application to call LoadConfig again.
+func (provider *Provider) RegisterErrorCallback(fn func(err error)) {
+	provider.mu.Lock()
+	provider.callbacks = append(provider.callbacks, fn)
+	if provider.watcher != nil {
+		provider.mu.Unlock()
+		return
+	}
+	err := provider.startWatching()
+	provider.mu.Unlock()
+
+	if err != nil {
+		fn(fmt.Errorf("failed to watch configuration files; %w", err))
+	}
+}
+
+// startWatching every known configuration file. Must be called while holding the lock.
+func (provider *Provider) startWatching() error {
+	w, err := newWatcher(provider.checkForChanges)
+	if err != nil {
+		return err
+	}
+	provider.watcher = w
+	for _, files := range []map[string]string{provider.infraSums, provider.appFiles} {
+		for path := range files {
+			if err := w.add(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Reload the infrastructure configuration and notify registered callbacks with the outcome.
+// The current Locator is only replaced if the new configuration is loaded successfully.
+func (provider *Provider) Reload() error {
+	err := provider.reload()
+	provider.notify(err)
+	return err
+}
+
+// Close stops watching configuration files for changes.
+func (provider *Provider) Close() error {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	if provider.watcher == nil {
+		return nil
+	}
+	err := provider.watcher.close()
+	provider.watcher = nil
+	return err
+}
+
+// reload the infrastructure configuration. Reloads are serialized so that a snapshot is never replaced by an older one.
+func (provider *Provider) reload() error {
+	provider.reloadMu.Lock()
+	defer provider.reloadMu.Unlock()
+
+	snapshot, err := provider.loadInfra()
+	if err != nil {
+		return fmt.Errorf("failed to reload infrastructure configuration; %w", err)
+	}
+	provider.infra.Store(snapshot)
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	if provider.watcher != nil {
+		for _, path := range snapshot.watched {
+			if err := provider.watcher.add(path); err != nil {
+				return fmt.Errorf("failed to watch %s; %w", path, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (provider *Provider) notify(err error) {
+	provider.mu.Lock()
+	callbacks := make([]func(err error), len(provider.callbacks))
+	copy(callbacks, provider.callbacks)
+	provider.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn(err)
+	}
+}
+
+// trackAppFile records an application configuration file so changes to it are notified.
+func (provider *Provider) trackAppFile(path string) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	provider.appFiles[path] = checksum(path)
+	if provider.watcher != nil {
+		// errors are ignored since the file was just read successfully and watching is best effort.
+		_ = provider.watcher.add(path)
+	}
+}
+
+// checkForChanges compares the checksum of every watched file with the last one seen and reloads or notifies
+// accordingly. Checksums are used because editors and orchestrators (e.g. kubernetes config maps) generate events
+// for files other than the ones being watched.
+func (provider *Provider) checkForChanges() {
+	provider.mu.Lock()
+	infraChanged := updateChecksums(provider.infraSums)
+	appChanged := updateChecksums(provider.appFiles)
+	provider.mu.Unlock()
+
+	switch {
+	case infraChanged:
+		provider.notify(provider.reload())
+	case appChanged:
+		provider.notify(nil)
+	}
+}
+
+func updateChecksums(sums map[string]string) (changed bool) {
+	for path, sum := range sums {
+		if current := checksum(path); current != sum {
+			sums[path] = current
+			changed = true
+		}
+	}
+	return changed
+}
+
+// checksum of the file contents, or of the list of files if path is a folder.
+// Returns an empty string if the path can not be read.
+func checksum(path string) string {
+	var data []byte
+	if entries, err := os.ReadDir(path); err == nil {
+		for _, entry := range entries {
+			data = append(data, entry.Name()+"\n"...)
+		}
+	} else if data, err = os.ReadFile(path); err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// watcher of file system events for the folders containing configuration files.
+// Folders are watched instead of files so that atomic renames and symlink swaps are also detected.
+type watcher struct {
+	fsw  *fsnotify.Watcher
+	dirs map[string]bool
+	done chan struct{}
+}
+
+func newWatcher(onChange func()) (*watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	w := &watcher{
+		fsw:  fsw,
+		dirs: make(map[string]bool),
+		done: make(chan struct{}),
+	}
+	go w.run(onChange)
+	return w, nil
+}
+
+// add the folder, or the folder containing the file, to the watch list.
+func (w *watcher) add(path string) error {
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		path = filepath.Dir(path)
+	}
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if w.dirs[dir] {
+		return nil
+	}
+	if err := w.fsw.Add(dir); err != nil {
+		return err
+	}
+	w.dirs[dir] = true
+	return nil
+}
+
+func (w *watcher) close() error {
+	close(w.done)
+	return w.fsw.Close()
+}
+
+func (w *watcher) run(onChange func()) {
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+	for {
+		select {
+		case <-w.done:
+			timer.Stop()
+			return
+		case _, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			timer.Reset(watchDebounce)
+		case _, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+		case <-timer.C:
+			onChange()
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package infrastructure
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func waitForCallback(t *testing.T, notifications chan error) error {
+	t.Helper()
+	select {
+	case err := <-notifications:
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for configuration change notification")
+		return nil
+	}
+}
+
+func TestProviderWatch(t *testing.T) {
+	infraDir := t.TempDir()
+	configDir := t.TempDir()
+	infraFile := filepath.Join(infraDir, "watch.json")
+	configFile := filepath.Join(configDir, "app.json")
+	writeFile(t, infraFile, `{"storage": {"redis": {"cache": {"address": "10.0.0.1:6379"}}}}`)
+	writeFile(t, configFile, `{"cache": {"arn": "arn://storage/redis/cache"}}`)
+
+	provider, err := NewProvider(ProviderSettings{
+		EnvName:            "watch",
+		SystemName:         "sys",
+		ComponentName:      "cmp",
+		InfraConfigFolders: []string{infraDir},
+		AppConfigFolders:   []string{configDir},
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer provider.Close()
+
+	var cfg struct {
+		Cache struct {
+			Resource string `json:"arn"`
+		} `json:"cache"`
+	}
+	if !assert.NoError(t, provider.LoadConfig("app", &cfg)) {
+		t.FailNow()
+	}
+
+	notifications := make(chan error, 10)
+	provider.RegisterErrorCallback(func(err error) { notifications <- err })
+
+	t.Run("infra change", func(t *testing.T) {
+		writeFile(t, infraFile, `{"storage": {"redis": {"cache": {"address": "10.0.0.2:6379"}}}}`)
+		assert.NoError(t, waitForCallback(t, notifications))
+		assert.Equal(t, "10.0.0.2:6379", provider.Locator().LocateRedisResource(cfg.Cache.Resource).Address)
+	})
+
+	t.Run("invalid infra keeps current locator", func(t *testing.T) {
+		writeFile(t, infraFile, `{"storage": {"redis": `)
+		assert.Error(t, waitForCallback(t, notifications))
+		assert.Equal(t, "10.0.0.2:6379", provider.Locator().LocateRedisResource(cfg.Cache.Resource).Address)
+	})
+
+	t.Run("app config change", func(t *testing.T) {
+		writeFile(t, configFile, `{"cache": {"arn": "arn://storage/redis/other"}}`)
+		assert.NoError(t, waitForCallback(t, notifications))
+		assert.NoError(t, provider.LoadConfig("app", &cfg))
+		assert.Equal(t, "arn://storage/redis/other", cfg.Cache.Resource)
+	})
+}
+
+func TestProviderReload(t *testing.T) {
+	infraDir := t.TempDir()
+	infraFile := filepath.Join(infraDir, "reload.json")
+	writeFile(t, infraFile, `{"webservices": {"api": {"url": "https://v1.example.com"}}}`)
+
+	provider, err := NewProvider(ProviderSettings{
+		EnvName:            "reload",
+		SystemName:         "sys",
+		ComponentName:      "cmp",
+		InfraConfigFolders: []string{infraDir},
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, infraFile, provider.ResourcePath())
+
+	var notified []error
+	provider.RegisterErrorCallback(func(err error) { notified = append(notified, err) })
+	var changes int
+	provider.RegisterCallback(func() { changes++ })
+	assert.NoError(t, provider.Close())
+
+	writeFile(t, infraFile, `{"webservices": {"api": {"url": "https://v2.example.com"}}}`)
+	assert.NoError(t, provider.Reload())
+	assert.Equal(t, "https://v2.example.com", provider.Locator().LocateWebserviceResource("arn://webservices/api").BaseURL)
+
+	writeFile(t, infraFile, `{"webservices": {"api": {"url": "https://v3.example.com"}`)
+	assert.Error(t, provider.Reload())
+	assert.Equal(t, "https://v2.example.com", provider.Locator().LocateWebserviceResource("arn://webservices/api").BaseURL)
+
+	if assert.Len(t, notified, 2) {
+		assert.NoError(t, notified[0])
+		assert.Error(t, notified[1])
+	}
+	assert.Equal(t, 1, changes)
+}
+
+func TestProviderConcurrentReloads(t *testing.T) {
+	infraDir := t.TempDir()
+	infraFile := filepath.Join(infraDir, "reload.json")
+	writeFile(t, infraFile, `{"webservices": {"api": {"url": "https://v1.example.com"}}}`)
+
+	provider, err := NewProvider(ProviderSettings{
+		EnvName:            "reload",
+		SystemName:         "sys",
+		ComponentName:      "cmp",
+		InfraConfigFolders: []string{infraDir},
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, provider.Reload())
+		}()
+	}
+	writeFile(t, infraFile, `{"webservices": {"api": {"url": "https://v2.example.com"}}}`)
+	wg.Wait()
+
+	// the last reload always reads the latest file, whatever the order of the concurrent ones.
+	assert.NoError(t, provider.Reload())
+	assert.Equal(t, "https://v2.example.com", provider.Locator().LocateWebserviceResource("arn://webservices/api").BaseURL)
+}
@@ -0,0 +1,137 @@
+package infrastructure
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/vredens/infrastructure/resources"
+)
+
+// variantsKey is the key used by resources to declare variants, such as replicas in each availability zone.
+// Each variant is an object with the settings which replace the ones of the resource and is selected by its
+// `az:<zone>` tag, e.g. `"variants": [{"tags": ["az:us-east-1b"], "address": "replica-b:6379"}]`.
+const variantsKey = "variants"
+
+// zoneTag is the prefix of the tag used to select variants of resources.
+const zoneTag = "az:"
+
+// selectVariants replaces, in place, every resource with variants by the variant in the first of the zones it has one
+// for. Resources without a variant in any of the zones are kept as they are.
+func selectVariants(doc map[string]interface{}, path []string, zones []string) error {
+	if !resources.IsCollection(path...) {
+		for key, value := range doc {
+			if child, ok := value.(map[string]interface{}); ok {
+				if err := selectVariants(child, append(path[:len(path):len(path)], key), zones); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	for name, value := range doc {
+		resource, ok := value.(map[string]interface{})
+		if !ok || resource[variantsKey] == nil {
+			continue
+		}
+		arn := arnOf(append(path[:len(path):len(path)], name))
+		variants, err := variantsOf(arn, resource[variantsKey])
+		if err != nil {
+			return err
+		}
+		base := make(map[string]interface{}, len(resource))
+		for key, value := range resource {
+			if key != variantsKey {
+				base[key] = value
+			}
+		}
+		doc[name] = base
+		if variant := variantFor(variants, zones); variant != nil {
+			doc[name] = mergeDocuments(base, withBaseTags(base, variant), ArrayReplace)
+		}
+	}
+	return nil
+}
+
+// withBaseTags returns a copy of the variant whose tags are the tags of the base followed by the ones of the variant
+// the base does not have, so that replacing the settings of the base does not drop its tags.
+func withBaseTags(base, variant map[string]interface{}) map[string]interface{} {
+	baseTags, _ := base["tags"].([]interface{})
+	variantTags, _ := variant["tags"].([]interface{})
+	tags := append(make([]interface{}, 0, len(baseTags)+len(variantTags)), baseTags...)
+	for _, tag := range variantTags {
+		if !hasTag(baseTags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	merged := make(map[string]interface{}, len(variant))
+	for key, value := range variant {
+		merged[key] = value
+	}
+	merged["tags"] = tags
+	return merged
+}
+
+func hasTag(tags []interface{}, tag interface{}) bool {
+	name, ok := tag.(string)
+	if !ok {
+		return false
+	}
+	for _, t := range tags {
+		if t, ok := t.(string); ok && t == name {
+			return true
+		}
+	}
+	return false
+}
+
+func variantsOf(arn string, value interface{}) ([]map[string]interface{}, error) {
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid %s in %s; expected a list of objects", variantsKey, arn)
+	}
+	variants := make([]map[string]interface{}, 0, len(items))
+	for _, item := range items {
+		variant, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid %s in %s; expected a list of objects", variantsKey, arn)
+		}
+		if zoneOf(variant) == "" {
+			return nil, fmt.Errorf("variant of %s without an %s<zone> tag", arn, zoneTag)
+		}
+		variants = append(variants, variant)
+	}
+	return variants, nil
+}
+
+// variantFor the first of the zones with one. Returns nil if there is none.
+func variantFor(variants []map[string]interface{}, zones []string) map[string]interface{} {
+	for _, zone := range zones {
+		for _, variant := range variants {
+			if zoneOf(variant) == zone {
+				return variant
+			}
+		}
+	}
+	return nil
+}
+
+// zoneOf the variant, as defined by its `az:<zone>` tag.
+func zoneOf(variant map[string]interface{}) string {
+	tags, _ := variant["tags"].([]interface{})
+	for _, tag := range tags {
+		if tag, ok := tag.(string); ok && strings.HasPrefix(tag, zoneTag) {
+			return strings.TrimPrefix(tag, zoneTag)
+		}
+	}
+	return ""
+}
+
+// zones in order of preference for selecting variants of resources.
+func (settings ProviderSettings) zones() []string {
+	var zones []string
+	if settings.AvailabilityZone != "" {
+		zones = append(zones, settings.AvailabilityZone)
+	}
+	return append(zones, settings.ZoneFallback...)
+}
@@ -0,0 +1,116 @@
+package infrastructure
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProviderZoneVariants(t *testing.T) {
+	infraDir := t.TempDir()
+	writeFile(t, filepath.Join(infraDir, "test.json"), `{
+		"storage": {
+			"redis": {
+				"cache": {
+					"address": "primary:6379",
+					"db": 1,
+					"tags": ["tier:1"],
+					"variants": [
+						{"tags": ["az:us-east-1a"], "address": "replica-a:6379"},
+						{"tags": ["az:us-east-1b"], "address": "replica-b:6379"}
+					]
+				},
+				"sessions": {"address": "sessions:6379"}
+			}
+		}
+	}`)
+
+	testCases := []struct {
+		zone     string
+		fallback []string
+		address  string
+	}{
+		{zone: "us-east-1b", address: "replica-b:6379"},
+		{zone: "us-east-1c", fallback: []string{"us-east-1d", "us-east-1a", "us-east-1b"}, address: "replica-a:6379"},
+		{zone: "us-east-1c", address: "primary:6379"},
+		{zone: "", address: "primary:6379"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.zone, func(t *testing.T) {
+			provider, err := NewProvider(ProviderSettings{
+				EnvName:            "test",
+				SystemName:         "sys",
+				ComponentName:      "cmp",
+				InfraConfigFolders: []string{infraDir},
+				AvailabilityZone:   tc.zone,
+				ZoneFallback:       tc.fallback,
+			})
+			if !assert.NoError(t, err) {
+				t.FailNow()
+			}
+			assert.Equal(t, tc.zone, provider.AvailabilityZone())
+			cache := provider.Locator().LocateRedisResource("arn://storage/redis/cache")
+			assert.NoError(t, cache.Validate())
+			assert.Equal(t, tc.address, cache.Address)
+			assert.Equal(t, 1, cache.DB)
+			assert.Contains(t, cache.Tags, "tier:1")
+			assert.Equal(t, "sessions:6379", provider.Locator().LocateRedisResource("arn://storage/redis/sessions").Address)
+		})
+	}
+
+	provider, err := NewProvider(ProviderSettings{
+		EnvName:            "test",
+		SystemName:         "sys",
+		ComponentName:      "cmp",
+		InfraConfigFolders: []string{infraDir},
+		AvailabilityZone:   "us-east-1a",
+	})
+	if assert.NoError(t, err) {
+		found, err := provider.Locator().Find("az=us-east-1a")
+		assert.NoError(t, err)
+		if assert.Len(t, found, 1) {
+			assert.Equal(t, "arn://storage/redis/cache", found[0].ARN)
+			assert.Equal(t, []string{"tier:1", "az:us-east-1a"}, found[0].Tags)
+		}
+		found, err = provider.Locator().Find("tier=1")
+		assert.NoError(t, err)
+		assert.Len(t, found, 1)
+	}
+}
+
+func TestProviderZoneVariantsFromMetadata(t *testing.T) {
+	infraDir := t.TempDir()
+	writeFile(t, filepath.Join(infraDir, "test.json"), `{
+		"storage": {"redis": {"cache": {"address": "primary:6379", "variants": [{"tags": ["az:us-west-2a"], "address": "replica:6379"}]}}}
+	}`)
+	writeFile(t, filepath.Join(infraDir, "metadata.json"), `{"ContainerID": "1", "AvailabilityZone": "us-west-2a"}`)
+	t.Setenv("ECS_CONTAINER_METADATA_FILE", filepath.Join(infraDir, "metadata.json"))
+
+	provider, err := NewProvider(ProviderSettings{
+		EnvName:            "test",
+		SystemName:         "sys",
+		ComponentName:      "cmp",
+		InfraConfigFolders: []string{infraDir},
+	})
+	if assert.NoError(t, err) {
+		assert.Equal(t, "us-west-2a", provider.AvailabilityZone())
+		assert.Equal(t, "replica:6379", provider.Locator().LocateRedisResource("arn://storage/redis/cache").Address)
+	}
+}
+
+func TestProviderZoneVariantsInvalid(t *testing.T) {
+	infraDir := t.TempDir()
+	writeFile(t, filepath.Join(infraDir, "test.json"), `{
+		"storage": {"redis": {"cache": {"address": "primary:6379", "variants": [{"address": "replica:6379"}]}}}
+	}`)
+	_, err := NewProvider(ProviderSettings{
+		EnvName:            "test",
+		SystemName:         "sys",
+		ComponentName:      "cmp",
+		InfraConfigFolders: []string{infraDir},
+	})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "variant of arn://storage/redis/cache without an az:<zone> tag")
+	}
+}